@@ -27,6 +27,15 @@ func (c *Canvas) Remove(basic ecs.BasicEntity) {
 	delete(c.ids, basic.ID())
 	c.render.Remove(basic)
 	c.mouse.Remove(basic)
+	for _, v := range c.objects {
+		if v.Entity.ID() != basic.ID() {
+			continue
+		}
+		if v.shadow != nil {
+			delete(c.ids, v.shadow.Entity.ID())
+			c.render.Remove(*v.shadow.Entity)
+		}
+	}
 }
 
 func (c *Canvas) New(w *ecs.World) {
@@ -39,6 +48,8 @@ func (c *Canvas) New(w *ecs.World) {
 }
 
 func (c *Canvas) Update(dt float32) {
+	// the pages of the font atlases drawn in the last frames may be emptied
+	fontFrame++
 	if c.refresh {
 		n := len(c.objects)
 		for i, v := range c.ready {
@@ -54,13 +65,28 @@ func (c *Canvas) Update(dt float32) {
 		c.refresh = false
 	}
 
-	for _, v := range c.objects {
+	for i, v := range c.objects {
+		if v.shadow != nil {
+			c.updateShadow(v, v.Render.StartZIndex+float32(i))
+		}
+		if v.animation != nil {
+			v.updateAnimation(dt)
+		}
 		if v.onUpdate != nil {
 			v.onUpdate(v, dt)
 		}
 		if v.Render.Hidden {
 			continue
 		}
+		if v.group != nil && v.Mouse != nil {
+			// the mouse system sets the point of the hovered shapes, through the camera
+			if v.Mouse.Hovered && v.group.masks(engo.Point{X: v.Mouse.MouseX, Y: v.Mouse.MouseY}) {
+				c.maskMouse(v)
+			}
+			// the shape is left once, when the mouse leaves it or goes out of the mask
+			v.Mouse.Leave = v.hovered && !v.Mouse.Hovered
+			v.hovered = v.Mouse.Hovered
+		}
 		if v.onHover[0] != nil {
 			if v.Mouse.Hovered {
 				v.onHover[0](v)
@@ -89,6 +115,20 @@ func (c *Canvas) Update(dt float32) {
 	}
 }
 
+// updateShadow adds the shadow of the shape just below it, the z-index of the shape is zIndex
+func (c *Canvas) updateShadow(s *Shape, zIndex float32) {
+	if _, ok := c.ids[s.Entity.ID()]; !ok {
+		return
+	}
+	shadow := s.shadow
+	if _, ok := c.ids[shadow.Entity.ID()]; !ok {
+		c.ids[shadow.Entity.ID()] = struct{}{}
+		shadow.Render.SetZIndex(zIndex - 0.5)
+		c.render.AddByInterface(shadow)
+	}
+	shadow.Render.Hidden = s.Render.Hidden || shadow.Render.Drawable.(*shapeCaster).Color == nil
+}
+
 func (c *Canvas) Push(shapes ...*Shape) {
 	for _, s := range shapes {
 		if s == nil {
@@ -100,6 +140,35 @@ func (c *Canvas) Push(shapes ...*Shape) {
 	}
 }
 
+// PushGroup pushes the shapes of the groups between the markers of their masks
+func (c *Canvas) PushGroup(groups ...*Group) {
+	for _, g := range groups {
+		c.Push(g.begin)
+		c.Push(g.Shapes...)
+		c.Push(g.end)
+	}
+}
+
+// RemoveGroup removes the shapes of the group and its markers
+func (c *Canvas) RemoveGroup(g *Group) {
+	c.Remove(*g.begin.Entity)
+	for _, s := range g.Shapes {
+		c.Remove(*s.Entity)
+	}
+	c.Remove(*g.end.Entity)
+}
+
+// maskMouse the mouse outside the mask of the group is not over the shape,
+// a drag started inside the mask goes on.
+func (c *Canvas) maskMouse(s *Shape) {
+	s.Mouse.Hovered = false
+	s.Mouse.Clicked = false
+	if s.mouseAction == MOUSE_NONE {
+		s.Mouse.Dragged = false
+		s.Mouse.Released = false
+	}
+}
+
 func (c *Canvas) GroupPush(groups ...Shapes) {
 	for _, g := range groups {
 		c.Push(g...)
@@ -127,18 +127,18 @@ func (scene *Scene) draw() {
 		{75, boxBounds[1] + ascent}, {350, boxBounds[1] + ascent},
 	}, 1, 0xF0F0, 2, 0x000000FF)
 	origin := engoutil.NewCircle(boxBounds[0]-5-5, boxBounds[1]+ascent, 3, 0, 0, 0, 0x000000FF)
-	lineGuideAscent := engoutil.NewStippleLine(engoutil.Points{
+	lineGuideAscent := engoutil.NewArrow(engoutil.Points{
 		// ascent vertical
-		{boxBounds[0] - 5 - 5, boxBounds[1] + 10}, {boxBounds[0] - 5 - 5, boxBounds[1] + ascent - 10},
-	}, 1, 0xFFFF, 2, 0x000000FF)
-	lineGuideDescent := engoutil.NewStippleLine(engoutil.Points{
+		{boxBounds[0] - 5 - 5, boxBounds[1] + ascent - 5}, {boxBounds[0] - 5 - 5, boxBounds[1] + 2},
+	}, 2, engoutil.ARROW_MARKER_TRIANGLE, engoutil.ARROW_MARKER_TRIANGLE, 0x000000FF)
+	lineGuideDescent := engoutil.NewArrow(engoutil.Points{
 		// descent vertical
-		{boxBounds[0] - 5 - 5, boxBounds[1] + ascent + 10}, {boxBounds[0] - 5 - 5, boxBounds[3] - 10},
-	}, 1, 0xFFFF, 2, 0x000000FF)
-	lineGuideLineHeight := engoutil.NewStippleLine(engoutil.Points{
+		{boxBounds[0] - 5 - 5, boxBounds[1] + ascent + 5}, {boxBounds[0] - 5 - 5, boxBounds[3] - 2},
+	}, 2, engoutil.ARROW_MARKER_TRIANGLE, engoutil.ARROW_MARKER_TRIANGLE, 0x000000FF)
+	lineGuideLineHeight := engoutil.NewArrow(engoutil.Points{
 		// line height vertical
-		{boxBounds[2] + 5 + 5, boxBounds[1] + 10}, {boxBounds[2] + 5 + 5, boxBounds[3] - 10},
-	}, 1, 0xFFFF, 2, 0x000000FF)
+		{boxBounds[2] + 5 + 5, boxBounds[1] + 2}, {boxBounds[2] + 5 + 5, boxBounds[3] - 2},
+	}, 2, engoutil.ARROW_MARKER_TRIANGLE, engoutil.ARROW_MARKER_TRIANGLE, 0x000000FF)
 	lineGuide := engoutil.NewStippleLine(engoutil.Points{
 		// left top
 		{boxBounds[0] - 5 - 10, boxBounds[1]}, {boxBounds[0] - 5, boxBounds[1]},
@@ -150,46 +150,12 @@ func (scene *Scene) draw() {
 		// right bottom
 		{boxBounds[2] + 5, boxBounds[3]}, {boxBounds[2] + 15, boxBounds[3]},
 	}, 1, 0xFFFF, 2, 0x000000FF)
-	arrowAscent := engoutil.Shapes{
-		// ascent top
-		engoutil.NewPolygon(boxBounds[0]-5-10, boxBounds[1]+2, 10, 10, 0, engoutil.Points{
-			{0, 1}, {1, 1}, {0.5, 0},
-		}, 0, 0x000000FF),
-		// ascent bottom
-		engoutil.NewPolygon(boxBounds[0]-5-10, boxBounds[1]+ascent-10-5, 10, 10, 0, engoutil.Points{
-			{0, 0}, {1, 0}, {0.5, 1},
-		}, 0, 0x000000FF),
-	}
-	arrowDescent := engoutil.Shapes{
-		// descent top
-		engoutil.NewPolygon(boxBounds[0]-5-10, boxBounds[1]+ascent+5, 10, 10, 0, engoutil.Points{
-			{0, 1}, {1, 1}, {0.5, 0},
-		}, 0, 0x000000FF),
-
-		// descent bottom
-		engoutil.NewPolygon(boxBounds[0]-5-10, boxBounds[3]-10-2, 10, 10, 0, engoutil.Points{
-			{0, 0}, {1, 0}, {0.5, 1},
-		}, 0, 0x000000FF),
-	}
-
-	arrowLineHeight := engoutil.Shapes{
-		// line height top
-		engoutil.NewPolygon(boxBounds[2]+5, boxBounds[1]+2, 10, 10, 0, engoutil.Points{
-			{0, 1}, {1, 1}, {0.5, 0},
-		}, 0, 0x000000FF),
-		// line height bottom
-		engoutil.NewPolygon(boxBounds[2]+5, boxBounds[3]-10-2, 10, 10, 0, engoutil.Points{
-			{0, 0}, {1, 0}, {0.5, 1},
-		}, 0, 0x000000FF),
-	}
-
 	rectBoundingBox := engoutil.NewRect(10, 10, 30, 20, 0, 0, 0xFA2C4440)
 	rectLeftSide := engoutil.NewRect(140, 10, 30, 20, 0, 0, 0x0079BA40)
 	rectRightSide := engoutil.NewRect(240, 10, 30, 20, 0, 0, 0xFFCE4440)
 
 	scene.canvas.Push(character, boxLeftSide, boxRightSide, boxBlank, blankCross, box, baseline, origin, lineGuide, rectBoundingBox, rectLeftSide, rectRightSide)
 	scene.canvas.Push(lineGuide, lineGuideAscent, lineGuideDescent, lineGuideLineHeight)
-	scene.canvas.GroupPush(arrowAscent, arrowDescent, arrowLineHeight)
 
 	box.OnHover(func(shape *engoutil.Shape) {
 		// when it's a solid line
@@ -237,16 +203,10 @@ func (scene *Scene) draw() {
 
 	textAscent.OnHover(func(s *engoutil.Shape) {
 		s.SetStrokeColor(0xFF0000FF)
-		lineGuideAscent.SetFillColor(0xFF0000FF)
-		for _, item := range arrowAscent {
-			item.SetFillColor(0xFF0000FF)
-		}
+		lineGuideAscent.SetStrokeColor(0xFF0000FF)
 	}, func(s *engoutil.Shape) {
 		s.SetStrokeColor(0x000000FF)
-		lineGuideAscent.SetFillColor(0x000000FF)
-		for _, item := range arrowAscent {
-			item.SetFillColor(0x000000FF)
-		}
+		lineGuideAscent.SetStrokeColor(0x000000FF)
 	})
 
 	textBaseline.OnHover(func(s *engoutil.Shape) {
@@ -260,30 +220,18 @@ func (scene *Scene) draw() {
 
 	textDescent.OnHover(func(s *engoutil.Shape) {
 		s.SetStrokeColor(0xFF0000FF)
-		lineGuideDescent.SetFillColor(0xFF0000FF)
-		for _, item := range arrowDescent {
-			item.SetFillColor(0xFF0000FF)
-		}
+		lineGuideDescent.SetStrokeColor(0xFF0000FF)
 	}, func(s *engoutil.Shape) {
 		s.SetStrokeColor(0x000000FF)
-		lineGuideDescent.SetFillColor(0x000000FF)
-		for _, item := range arrowDescent {
-			item.SetFillColor(0x000000FF)
-		}
+		lineGuideDescent.SetStrokeColor(0x000000FF)
 	})
 
 	textLineHeight.OnHover(func(s *engoutil.Shape) {
 		s.SetStrokeColor(0xFF0000FF)
-		lineGuideLineHeight.SetFillColor(0xFF0000FF)
-		for _, item := range arrowLineHeight {
-			item.SetFillColor(0xFF0000FF)
-		}
+		lineGuideLineHeight.SetStrokeColor(0xFF0000FF)
 	}, func(s *engoutil.Shape) {
 		s.SetStrokeColor(0x000000FF)
-		lineGuideLineHeight.SetFillColor(0x000000FF)
-		for _, item := range arrowLineHeight {
-			item.SetFillColor(0x000000FF)
-		}
+		lineGuideLineHeight.SetStrokeColor(0x000000FF)
 	})
 
 	scene.canvas.Push(textBaseline, textAdvance, textAscent, textDescent, textLineHeight, textBoundingBox, textLeftSide, textRightSide)
@@ -259,6 +259,8 @@ func newButton(text string, x, y float32) *Button {
 				// Manually set the size to fill the background color
 				Width:  60,
 				Height: 30,
+				Align:  engoutil.TEXT_ALIGN_CENTER,
+				VAlign: engoutil.TEXT_VALIGN_MIDDLE,
 			}),
 			engoutil.NewStippleRect(x, y, 60, 30, 1, 0xFFFF, 2, 0x000000FF),
 		},
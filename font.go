@@ -2,22 +2,27 @@ package engoutil
 
 import (
 	"fmt"
-	"image"
-	"image/color"
 	"io/ioutil"
 
 	"github.com/EngoEngine/engo"
 	"github.com/EngoEngine/engo/common"
 	"github.com/EngoEngine/gl"
+	"github.com/EngoEngine/math"
 	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/font"
 	"golang.org/x/image/math/fixed"
 )
 
-// font texture default width
+// font texture default width, the default size of the pages of the font atlases
 const fontTextureDefWidth = 2048
 
+// the empty pixels around the characters in the font texture, the blur of the text shadow and the outline are limited to it
+const fontTexturePadding = 8
+
+// textOutlineMaxRadius the outline of the bitmap atlases, the shader samples the (2r+1)² texels around a pixel, 17×17 at most
+const textOutlineMaxRadius = fontTexturePadding
+
 type Padding struct {
 	Top, Right, Bottom, Left float32
 }
@@ -28,9 +33,12 @@ const (
 )
 
 type Font struct {
-	URL   string
-	Size  float64
-	TTF   *truetype.Font
+	URL  string
+	Size float64
+	TTF  *truetype.Font
+	// SDF the characters are drawn from a signed distance field atlas shared by all the sizes of the face,
+	// they stay crisp when the text is scaled or rotated
+	SDF   bool
 	face  font.Face
 	scale float32
 }
@@ -48,6 +56,7 @@ func (f *Font) Create() error {
 		return err
 	}
 	f.TTF = ttf
+	parseShapingFace(f.URL, ttfBytes)
 	f.face = truetype.NewFace(f.TTF, &truetype.Options{
 		Size:    f.Size,
 		Hinting: font.HintingFull,
@@ -78,164 +87,73 @@ func (f *Font) CreatePreloaded() error {
 	return nil
 }
 
-func (f *Font) updateFontAtlas(text string) (atlas *FontAtlas) {
-
-	var initialized bool
-	if _, ok := atlasCache[*f]; ok {
-		atlas = atlasCache[*f]
-		initialized = true
-	} else {
-		atlas = &FontAtlas{
-			XLocation: make(map[rune]float32),
-			YLocation: make(map[rune]float32),
-			Width:     make(map[rune]float32),
-			Height:    make(map[rune]float32),
-			// Use fixed width, when the capacity is insufficient, only expand the height
-			TotalWidth: fontTextureDefWidth,
-			Ascent:     float32(f.face.Metrics().Ascent.Ceil()),
-			LeftSide:   make(map[rune]float32),
-			RightSide:  make(map[rune]float32),
-			OffsetY:    make(map[rune]float32),
-			LineHeight: float32((f.face.Metrics().Ascent + f.face.Metrics().Descent).Ceil()),
-		}
-		atlasCache[*f] = atlas
+// updateFontAtlas adds the glyphs to the atlas of the font, see truetype.Font.Index
+func (f *Font) updateFontAtlas(glyphs []truetype.Index) (atlas *FontAtlas) {
+	if f.SDF {
+		return f.updateSDFAtlas(glyphs)
 	}
 
+	atlas, initialized := atlasCache[*f]
 	if !initialized {
-		// Generate basic character 0x20(Space) ~ 0x7E(~)
-		basic := make([]rune, 95)
-		for i := range basic {
-			basic[i] = rune(i) + 32
-		}
-		text = string(basic) + text
-	}
-
-	var (
-		// characters that need to be updated
-		fresh         []rune
-		w, h, advance float32
-		prev          rune
-		ascent        = make(map[rune]float32)
-	)
-
-	for _, char := range text {
-		if _, has := atlas.Width[char]; has {
-			continue
-		}
-		bounds, adv, ok := f.face.GlyphBounds(char)
-		if !ok {
-			continue
-		}
-
-		fresh = append(fresh, char)
-
-		w = float32((bounds.Max.X - bounds.Min.X).Ceil())
-		h = float32((bounds.Max.Y - bounds.Min.Y).Ceil())
-		advance = float32(adv.Ceil())
-		ascent[char] = float32(-bounds.Min.Y.Ceil())
-
-		atlas.Width[char] = w
-		atlas.Height[char] = h
-		atlas.LeftSide[char] = float32(bounds.Min.X.Ceil())
-		atlas.RightSide[char] = advance - float32(bounds.Max.X.Ceil())
-		atlas.OffsetY[char] = atlas.Ascent - ascent[char]
-
-		if prev > 0 {
-			atlas.CurrentX += float32(f.face.Kern(prev, char).Ceil())
-		}
-
-		// partially overlapped characters
-		if w > advance {
-			if atlas.LeftSide[char] < 0 {
-				advance -= atlas.LeftSide[char]
-			} else if atlas.RightSide[char] < 0 {
-				advance -= atlas.RightSide[char]
-			}
-		}
-
-		// position correction of overlapped characters
-		if atlas.LeftSide[char] < 0 {
-			atlas.CurrentX -= atlas.LeftSide[char]
-		}
-
-		if atlas.CurrentX+advance > atlas.TotalWidth {
-			atlas.CurrentX = 0
-			atlas.CurrentY += atlas.LineHeight
-			prev = 0
-		}
-
-		atlas.XLocation[char] = atlas.CurrentX
-		atlas.YLocation[char] = atlas.CurrentY
-		atlas.CurrentX += advance
-		prev = char
-	}
-	if len(fresh) > 0 {
-		atlas.TotalHeight = atlas.CurrentY + atlas.LineHeight
-	}
-
-	var actual *image.NRGBA
-
-	// When the image is nil or needs to be updated
-	if atlas.Image == nil {
-		actual = image.NewNRGBA(image.Rect(0, 0, int(atlas.TotalWidth), int(atlas.TotalHeight)))
-	} else if len(fresh) > 0 {
-		actual = atlas.Image
-		if int(atlas.TotalHeight) > atlas.Image.Rect.Max.Y {
-			resizeNRGBAHeight(actual, atlas.TotalHeight)
-		}
-	}
-
-	if len(fresh) > 0 {
-		d := &font.Drawer{}
-		d.Src = image.NewUniform(color.White)
-		d.Face = f.face
-		d.Dst = actual
-		for _, char := range fresh {
-			// draw on baseline
-			d.Dot = fixed.P(int(atlas.XLocation[char]), int(atlas.YLocation[char]+atlas.Ascent))
-			d.DrawString(string(char))
-			// position correction
-			atlas.XLocation[char] += atlas.LeftSide[char]
-			atlas.YLocation[char] += atlas.OffsetY[char]
+		scale := f.scale
+		if scale == 0 {
+			scale = 1
 		}
+		atlas = newFontAtlas(f.TTF, f.Size*float64(scale), font.HintingFull, fontTexturePadding)
+		atlasCache[*f] = atlas
+		glyphs = append(basicGlyphs(f.TTF), glyphs...)
 	}
+	atlas.update(glyphs)
+	return
+}
 
-	if actual != nil {
-		atlas.Image = actual
-		atlas.Texture = common.NewTextureSingle(common.NewImageObject(actual)).Texture()
+// basicGlyphs the glyphs of the characters 0x20(Space) ~ 0x7E(~)
+func basicGlyphs(ttf *truetype.Font) []truetype.Index {
+	basic := make([]truetype.Index, 95)
+	for i := range basic {
+		basic[i] = ttf.Index(rune(i) + 32)
 	}
-	return
+	return basic
 }
 
-// A FontAtlas is a representation of some of the Font characters, as an image
+// A FontAtlas is a representation of some of the Font glyphs, as the images of its pages.
+// the glyphs are the indices of the font, see truetype.Font.Index, the shaper picks them for the characters
 type FontAtlas struct {
-	Image   *image.NRGBA
-	Texture *gl.Texture
-
-	// XLocation contains the X-coordinate of the starting position of all characters
-	XLocation map[rune]float32
-	// YLocation contains the Y-coordinate of the starting position of all characters
-	YLocation map[rune]float32
-	// Width contains the width in pixels of all the characters, including the spacing between characters
-	Width map[rune]float32
-	// Height contains the height in pixels of all the characters
-	Height map[rune]float32
-	// TotalWidth is the total amount of pixels the `FontAtlas` is wide; useful for determining the `Viewport`,
-	// which is relative to this value.
-	TotalWidth float32
-	// TotalHeight is the total amount of pixels the `FontAtlas` is high; useful for determining the `Viewport`,
-	// which is relative to this value.
-	TotalHeight float32
-	// The position of the last character
-	CurrentX, CurrentY float32
+	// Pages the textures of the glyphs, see SetFontAtlasPages. A full atlas evicts the glyphs drawn the longest time ago
+	Pages []*AtlasPage
+	// Page contains the index of the page of all glyphs
+	Page map[truetype.Index]int
+	// XLocation contains the X-coordinate of the starting position of all glyphs, in their pages
+	XLocation map[truetype.Index]float32
+	// YLocation contains the Y-coordinate of the starting position of all glyphs, in their pages
+	YLocation map[truetype.Index]float32
+	// Width contains the width in pixels of all the glyphs
+	Width map[truetype.Index]float32
+	// Height contains the height in pixels of all the glyphs
+	Height map[truetype.Index]float32
 
 	// LineHeight is Ascent+Descent
 	LineHeight float32
 	Ascent     float32
 	// left-side and right-side bearings
-	LeftSide, RightSide map[rune]float32
+	LeftSide, RightSide map[truetype.Index]float32
 	// OffsetY Ascent + bounds.Min.Y
-	OffsetY map[rune]float32
+	OffsetY map[truetype.Index]float32
+
+	// Size the font size of the signed distance field atlas, see Font.SDF
+	Size float32
+	// Distance the alpha of the signed distance field atlas is the distance to the edges up to this far,
+	// in pixels. zero for the bitmap atlases
+	Distance float32
+	ttf      *truetype.Font
+	// the size of the glyphs in 26.6 pixels, and their hinting
+	scale    fixed.Int26_6
+	hinting  font.Hinting
+	glyphBuf truetype.GlyphBuf
+	// the empty pixels around the glyphs in the pages
+	padding int
+	// the rectangles of the glyphs in the pages, and the frames they were drawn in
+	slots map[truetype.Index]*glyphSlot
 }
 
 // Text represents a string drawn onto the screen, as used by the `TextShader`.
@@ -244,10 +162,16 @@ type Text struct {
 	Font *Font
 	// Text is the actual text you want to draw. This may include newlines (\n).
 	Text string
+	// Spans the rich text, Text is the text of the spans. nil for plain text
+	Spans []Span
 	// LineSpacing is the amount of additional spacing there is between the lines (when `Text` consists of multiple lines).
 	LineSpacing float32
 	// LetterSpacing is the amount of additional spacing there is between the characters.
 	LetterSpacing float32
+	// Kerning the glyphs are kerned by the shaper, or by the kern table of the fonts not shaped, see PreloadFont
+	Kerning bool
+	// Fallbacks the urls of the fonts of the characters missing from the font, before SetFallbackFonts
+	Fallbacks []string
 
 	// The shader uses the position here instead of the SpaceComponent.Position.
 	// Because Padding changes size and position of SpaceComponent.
@@ -256,6 +180,15 @@ type Text struct {
 	Color *Color
 	// BG fill style, BG_FILL_FULL or BG_FILL_WRAP
 	BgStyle uint8
+	// the alignment of the lines inside the box
+	Align  TextAlign
+	VAlign TextVAlign
+	// Shadow of the characters, nil without shadow
+	Shadow *Shadow
+	// the outline around the characters, in pixels, up to the padding of the font texture,
+	// or to the distances of the SDF atlas
+	OutlineWidth float32
+	OutlineColor *Color
 	// Only when the BgStyle is BG_TYPE_FULL.
 	// This changes the size of the entity (common.SpaceComponent).
 	// In order to make common.MouseComponent working.
@@ -266,61 +199,42 @@ type Text struct {
 		text          string
 		lineSpacing   float32
 		letterSpacing float32
+		kerning       bool
+		shadow        [3]float32
+		outline       float32
+		width, height float32
+		align         TextAlign
+		valign        TextVAlign
+		spans         []Span
+		fallbacks     []string
+		// the quads of the glyphs drawn together, by their pages and colors
+		runs []textRun
+		// the quads of the glyphs and of the underlines of the layout, the buffer is sized for them
+		quads int
+		// glyphs were missing from the full pages of their atlases
+		missing bool
 	}
 	// The size calculated from the last rendering
 	size [2]float32
-	// The original size of the font. Used to change the size of the background,
-	// this does not include padding.
+	// The size of the box, the lines are wrapped at its width and aligned inside it.
+	// Used to change the size of the background, this does not include padding.
 	width, height float32
+	blending
 }
 
 // Texture returns nil because the Text is generated from a FontAtlas. This implements the common.Drawable interface.
 func (t Text) Texture() *gl.Texture { return nil }
 
-// Width returns the width of the Text generated from a FontAtlas. This implements the common.Drawable interface.
-func (t Text) Width() (width float32) {
-	atlas := t.Font.updateFontAtlas(t.Text)
-
-	var currentX float32
-
-	for _, char := range []rune(t.Text) {
-		// skip invisible characters
-		if _, ok := atlas.Width[char]; !ok {
-			continue
-		}
-		if char == '\n' {
-			if width < currentX {
-				width = currentX
-			}
-			currentX = 0
-			continue
-		}
-		currentX += atlas.LeftSide[char] + atlas.Width[char] + atlas.RightSide[char] + t.LetterSpacing
-		if width < currentX {
-			width = currentX
-		}
-	}
-	return
+// Width returns the width of the Text generated from a FontAtlas, the width of the box when it is set.
+// This implements the common.Drawable interface.
+func (t Text) Width() float32 {
+	return t.layout().width
 }
 
-// Height returns the height the Text generated from a FontAtlas. This implements the common.Drawable interface.
-func (t Text) Height() (height float32) {
-	atlas := t.Font.updateFontAtlas(t.Text)
-
-	var currentY float32
-
-	for _, char := range []rune(t.Text) {
-		// skip invisible characters
-		if _, ok := atlas.Height[char]; !ok {
-			continue
-		}
-		if char == '\n' {
-			currentY += atlas.LineHeight + t.LineSpacing
-			continue
-		}
-	}
-	height = currentY + atlas.LineHeight
-	return
+// Height returns the height the Text generated from a FontAtlas, the height of the box when it is set.
+// This implements the common.Drawable interface.
+func (t Text) Height() float32 {
+	return t.layout().height
 }
 
 func (t Text) View() (float32, float32, float32, float32) { return 0, 0, 1, 1 }
@@ -330,5 +244,50 @@ func (t Text) Close() {}
 func (t Text) Length() int { return len([]rune(t.Text)) }
 
 func (t Text) changed() bool {
-	return t.buffered.text != t.Text || t.buffered.lineSpacing != t.LineSpacing || t.buffered.letterSpacing != t.LetterSpacing
+	return t.buffered.text != t.Text || t.buffered.lineSpacing != t.LineSpacing || t.buffered.letterSpacing != t.LetterSpacing || t.buffered.kerning != t.Kerning ||
+		t.buffered.shadow != t.shadowQuad() || t.buffered.outline != t.outlineRadius() || t.buffered.width != t.width || t.buffered.height != t.height ||
+		t.buffered.align != t.Align || t.buffered.valign != t.VAlign || !spansEqual(t.buffered.spans, t.Spans) ||
+		!stringsEqual(t.buffered.fallbacks, t.Fallbacks) ||
+		t.buffered.missing || t.pagesChanged()
+}
+
+// pagesChanged the pages of the atlases shared with other texts may grow, it moves the texture coordinates,
+// or be emptied for other characters
+func (t Text) pagesChanged() bool {
+	for _, run := range t.buffered.runs {
+		if run.page != nil && run.page.generation != run.generation {
+			return true
+		}
+	}
+	return false
+}
+
+// shadowQuad the offset and the blur radius of the shadow, in pixels of the font texture
+func (t Text) shadowQuad() (quad [3]float32) {
+	if t.Shadow == nil {
+		return
+	}
+	scale := t.Font.scale
+	if scale == 0 {
+		scale = 1
+	}
+	// 3 sigma, sigma is half of the blur
+	radius := math.Min(1.5*t.Shadow.Blur*scale, t.Font.padding())
+	return [3]float32{t.Shadow.DX * scale, t.Shadow.DY * scale, math.Max(radius, 0)}
+}
+
+// outlineRadius the width of the outline, in pixels of the font texture
+func (t Text) outlineRadius() float32 {
+	if t.OutlineWidth <= 0 {
+		return 0
+	}
+	scale := t.Font.scale
+	if scale == 0 {
+		scale = 1
+	}
+	limit := t.Font.padding()
+	if !t.Font.SDF {
+		limit = math.Min(limit, textOutlineMaxRadius)
+	}
+	return math.Min(t.OutlineWidth*scale, limit)
 }
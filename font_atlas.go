@@ -0,0 +1,510 @@
+package engoutil
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/gl"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// The glyphs of a font atlas are packed in pages, the pages grow in height up to the page size.
+// Each page keeps its texture, only the rectangles of the new glyphs are uploaded,
+// the texture grows on the GPU, the glyphs are copied from the old texture.
+// When all the pages of an atlas are full, the glyph drawn the longest time ago with room for the new glyph
+// is evicted, the new glyph takes its place. The texts of the page lay their glyphs out again.
+
+var (
+	fontPageSize = fontTextureDefWidth
+	fontMaxPages = 4
+)
+
+// the height of the new pages
+const fontPageMinHeight = 256
+
+// SetFontAtlasPages the size of the square pages of the font atlases, and the number of pages of an atlas.
+// the size must be within GL_MAX_TEXTURE_SIZE, the new atlases use them.
+// The glyphs are evicted one by one: when the pages of an atlas are full, the glyph drawn the longest time ago,
+// as large as the new one, is removed, the texts still showing it rasterize it again. A page is emptied whole
+// only when the new glyph is larger than all the glyphs not drawn in the current frame.
+func SetFontAtlasPages(size, maxPages int) {
+	if size < 64 || maxPages < 1 {
+		warning("SetFontAtlasPages(), invalid page size %d or number of pages %d", size, maxPages)
+		return
+	}
+	fontPageSize, fontMaxPages = size, maxPages
+}
+
+// the frames of the Canvas, the glyphs drawn or added in the current frame are not evicted
+var fontFrame uint64
+
+// AtlasPage a texture of the glyphs of a font atlas
+type AtlasPage struct {
+	Image   *image.NRGBA
+	Texture *gl.Texture
+	// the size of the texture, the height grows up to the size of the page
+	Width, Height float32
+
+	size    int
+	skyline []skylineNode
+	// the rectangles of the evicted glyphs, below the skyline
+	free []image.Rectangle
+	// the frame the page was drawn in last
+	lastUsed uint64
+	// it changes when the page grows, is emptied or loses glyphs, the texture coordinates of the texts are outdated
+	generation int
+	// the rectangle of the image not uploaded yet, and the height of the texture
+	dirty         image.Rectangle
+	textureHeight int
+}
+
+// glyphSlot the rectangle of a glyph in its page, with the padding around it
+type glyphSlot struct {
+	page int
+	rect image.Rectangle
+	// the frame the glyph was laid out or drawn in last
+	lastUsed uint64
+}
+
+// skylineNode a segment of the top of the packed glyphs
+type skylineNode struct {
+	x, y, width int
+}
+
+func newAtlasPage() *AtlasPage {
+	size := fontPageSize
+	height := fontPageMinHeight
+	if height > size {
+		height = size
+	}
+	return &AtlasPage{
+		Image:   image.NewNRGBA(image.Rect(0, 0, size, height)),
+		Width:   float32(size),
+		Height:  float32(height),
+		size:    size,
+		skyline: []skylineNode{{0, 0, size}},
+		dirty:   image.Rect(0, 0, size, height),
+	}
+}
+
+// fit the lowest position of a rectangle on the skyline, the index of its node. false when the page is full
+func (p *AtlasPage) fit(w, h int) (index, x, y int, ok bool) {
+	index, y = -1, p.size
+	for i, node := range p.skyline {
+		if node.x+w > p.size {
+			break
+		}
+		top := 0
+		for j, covered := i, 0; covered < w; j++ {
+			if p.skyline[j].y > top {
+				top = p.skyline[j].y
+			}
+			covered += p.skyline[j].width
+		}
+		if top+h <= p.size && top < y {
+			index, x, y = i, node.x, top
+		}
+	}
+	return index, x, y, index >= 0
+}
+
+// insert raises the skyline over the rectangle placed at the node index
+func (p *AtlasPage) insert(index, x, y, w, h int) {
+	p.skyline = append(p.skyline[:index], append([]skylineNode{{x, y + h, w}}, p.skyline[index:]...)...)
+	// the nodes under the rectangle are shortened or removed
+	for i := index + 1; i < len(p.skyline); {
+		node := &p.skyline[i]
+		if node.x >= x+w {
+			break
+		}
+		if node.x+node.width <= x+w {
+			p.skyline = append(p.skyline[:i], p.skyline[i+1:]...)
+			continue
+		}
+		node.width -= x + w - node.x
+		node.x = x + w
+		break
+	}
+	for i := 0; i+1 < len(p.skyline); {
+		if p.skyline[i].y == p.skyline[i+1].y {
+			p.skyline[i].width += p.skyline[i+1].width
+			p.skyline = append(p.skyline[:i+1], p.skyline[i+2:]...)
+			continue
+		}
+		i++
+	}
+}
+
+// grow doubles the height of the page until the bottom is inside it
+func (p *AtlasPage) grow(bottom int) {
+	height := int(p.Height)
+	for height < bottom {
+		height *= 2
+	}
+	if height > p.size {
+		height = p.size
+	}
+	if height <= int(p.Height) {
+		return
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, p.size, height))
+	copy(img.Pix, p.Image.Pix)
+	p.dirty = p.dirty.Union(image.Rect(0, int(p.Height), p.size, height))
+	p.Image = img
+	p.Height = float32(height)
+	p.generation++
+}
+
+// clear empties the page for new glyphs
+func (p *AtlasPage) clear() {
+	for i := range p.Image.Pix {
+		p.Image.Pix[i] = 0
+	}
+	p.skyline = []skylineNode{{0, 0, p.size}}
+	p.free = p.free[:0]
+	p.generation++
+	p.dirty = p.Image.Rect
+}
+
+// upload the dirty rectangle of the image to the texture of the page, the distance fields are interpolated.
+// the new textures are uploaded whole, the grown ones copy the old texture, the texture of the page is left bound
+func (p *AtlasPage) upload(linear bool) {
+	height := int(p.Height)
+	if engo.Headless() {
+		p.dirty, p.textureHeight = image.Rectangle{}, height
+		return
+	}
+	switch {
+	case p.Texture == nil:
+		p.Texture = newPageTexture(linear)
+		engo.Gl.TexImage2D(engo.Gl.TEXTURE_2D, 0, engo.Gl.RGBA, engo.Gl.RGBA, engo.Gl.UNSIGNED_BYTE, p.Image)
+		p.dirty, p.textureHeight = image.Rectangle{}, height
+		return
+	case p.textureHeight < height:
+		p.growTexture(linear)
+	default:
+		engo.Gl.BindTexture(engo.Gl.TEXTURE_2D, p.Texture)
+	}
+	if r := p.dirty.Intersect(p.Image.Rect); !r.Empty() {
+		texSubImage2D(p.Image, r)
+	}
+	p.dirty = image.Rectangle{}
+}
+
+// fitFree the smallest free rectangle with room for w x h pixels, its index. false without one
+func (p *AtlasPage) fitFree(w, h int) (index int, ok bool) {
+	index = -1
+	for i, r := range p.free {
+		if r.Dx() >= w && r.Dy() >= h && (index < 0 || r.Dx()*r.Dy() < p.free[index].Dx()*p.free[index].Dy()) {
+			index = i
+		}
+	}
+	return index, index >= 0
+}
+
+// takeFree places w x h pixels at the top left of the free rectangle, the rest on its right and below it stay free
+func (p *AtlasPage) takeFree(index, w, h int) (x, y int) {
+	r := p.free[index]
+	p.free = append(p.free[:index], p.free[index+1:]...)
+	right := image.Rect(r.Min.X+w, r.Min.Y, r.Max.X, r.Min.Y+h)
+	below := image.Rect(r.Min.X, r.Min.Y+h, r.Max.X, r.Max.Y)
+	for _, rest := range []image.Rectangle{right, below} {
+		if !rest.Empty() {
+			p.free = append(p.free, rest)
+		}
+	}
+	return r.Min.X, r.Min.Y
+}
+
+// growTexture a texture of the height of the page, the glyphs are copied from the framebuffer of the old texture.
+// the new rows are dirty since the page grew
+func (p *AtlasPage) growTexture(linear bool) {
+	texture := newPageTexture(linear)
+	engo.Gl.TexImage2DEmpty(engo.Gl.TEXTURE_2D, 0, engo.Gl.RGBA, engo.Gl.RGBA, engo.Gl.UNSIGNED_BYTE, p.size, int(p.Height))
+	fb := engo.Gl.CreateFrameBuffer()
+	engo.Gl.BindFrameBuffer(fb)
+	engo.Gl.FrameBufferTexture2D(engo.Gl.FRAMEBUFFER, engo.Gl.COLOR_ATTACHMENT0, engo.Gl.TEXTURE_2D, p.Texture, 0)
+	copyTexSubImage2D(p.size, p.textureHeight)
+	engo.Gl.BindFrameBuffer(nil)
+	engo.Gl.DeleteFrameBuffer(fb)
+	engo.Gl.DeleteTexture(p.Texture)
+	p.Texture, p.textureHeight = texture, int(p.Height)
+}
+
+// packRect the pixels of the rectangle of the image, without the rest of its rows
+func packRect(img *image.NRGBA, r image.Rectangle) []uint8 {
+	row := 4 * r.Dx()
+	pix := make([]uint8, row*r.Dy())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i := img.PixOffset(r.Min.X, y)
+		copy(pix[(y-r.Min.Y)*row:], img.Pix[i:i+row])
+	}
+	return pix
+}
+
+// newPageTexture a texture bound, with the parameters of common.UploadTexture
+func newPageTexture(linear bool) *gl.Texture {
+	texture := engo.Gl.CreateTexture()
+	engo.Gl.BindTexture(engo.Gl.TEXTURE_2D, texture)
+	engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_WRAP_S, engo.Gl.CLAMP_TO_EDGE)
+	engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_WRAP_T, engo.Gl.CLAMP_TO_EDGE)
+	engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_MIN_FILTER, engo.Gl.LINEAR)
+	if linear {
+		engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_MAG_FILTER, engo.Gl.LINEAR)
+	} else {
+		engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_MAG_FILTER, engo.Gl.NEAREST)
+	}
+	return texture
+}
+
+// newFontAtlas the glyphs of the font rasterized at size, in pixels
+func newFontAtlas(ttf *truetype.Font, size float64, hinting font.Hinting, padding int) *FontAtlas {
+	metrics := truetype.NewFace(ttf, &truetype.Options{Size: size, Hinting: hinting}).Metrics()
+	return &FontAtlas{
+		Page:       make(map[truetype.Index]int),
+		XLocation:  make(map[truetype.Index]float32),
+		YLocation:  make(map[truetype.Index]float32),
+		Width:      make(map[truetype.Index]float32),
+		Height:     make(map[truetype.Index]float32),
+		Ascent:     float32(metrics.Ascent.Ceil()),
+		LeftSide:   make(map[truetype.Index]float32),
+		RightSide:  make(map[truetype.Index]float32),
+		OffsetY:    make(map[truetype.Index]float32),
+		LineHeight: float32((metrics.Ascent + metrics.Descent).Ceil()),
+		ttf:        ttf,
+		scale:      fixed.Int26_6(size*64 + 0.5),
+		hinting:    hinting,
+		padding:    padding,
+		slots:      make(map[truetype.Index]*glyphSlot),
+	}
+}
+
+// update adds the glyphs missing from the atlas, the glyphs are rasterized,
+// or their signed distance fields are drawn for the SDF atlases
+func (a *FontAtlas) update(glyphs []truetype.Index) {
+	skipped := 0
+	for _, glyph := range glyphs {
+		if slot, has := a.slots[glyph]; has {
+			slot.lastUsed = fontFrame
+			continue
+		}
+		if err := a.glyphBuf.Load(a.ttf, a.scale, glyph, a.hinting); err != nil {
+			continue
+		}
+		// the pixels of the glyph around its origin, y down
+		bounds := image.Rect(a.glyphBuf.Bounds.Min.X.Floor(), (-a.glyphBuf.Bounds.Max.Y).Floor(),
+			a.glyphBuf.Bounds.Max.X.Ceil(), (-a.glyphBuf.Bounds.Min.Y).Ceil())
+		if bounds.Empty() {
+			// the glyphs without outline, the spaces
+			bounds = image.Rectangle{}
+		}
+		w, h := bounds.Dx(), bounds.Dy()
+		index, x, y, ok := a.place(w, h)
+		if !ok {
+			skipped++
+			continue
+		}
+		page := a.Pages[index]
+		// the texts laid out with it are drawn in this frame
+		page.lastUsed = fontFrame
+		a.slots[glyph] = &glyphSlot{
+			page:     index,
+			rect:     image.Rect(x-a.padding, y-a.padding, x+w+a.padding, y+h+a.padding),
+			lastUsed: fontFrame,
+		}
+
+		a.Page[glyph] = index
+		a.XLocation[glyph] = float32(x)
+		a.YLocation[glyph] = float32(y)
+		a.Width[glyph] = float32(w)
+		a.Height[glyph] = float32(h)
+		a.LeftSide[glyph] = float32(bounds.Min.X)
+		a.RightSide[glyph] = float32(a.glyphBuf.AdvanceWidth.Round() - bounds.Max.X)
+		a.OffsetY[glyph] = a.Ascent + float32(bounds.Min.Y)
+		if a.Distance > 0 {
+			a.drawDistanceField(page, x, y, bounds)
+		} else {
+			// draw on baseline
+			drawGlyph(&a.glyphBuf, page.Image, image.Rect(x, y, x+w, y+h), image.Pt(x-bounds.Min.X, y-bounds.Min.Y))
+		}
+		page.dirty = page.dirty.Union(image.Rect(x-a.padding, y-a.padding, x+w+a.padding, y+h+a.padding))
+	}
+	if skipped > 0 {
+		warning("%d glyphs do not fit in the pages of the font atlas, see SetFontAtlasPages", skipped)
+	}
+	for _, page := range a.Pages {
+		if !page.dirty.Empty() {
+			page.upload(a.Distance > 0)
+		}
+	}
+}
+
+// drawGlyph rasterizes the outline of the glyph loaded in the buffer into the rectangle r of dst, in white,
+// with the origin of the glyph at origin
+func drawGlyph(g *truetype.GlyphBuf, dst draw.Image, r image.Rectangle, origin image.Point) {
+	if r.Empty() {
+		return
+	}
+	z := vector.NewRasterizer(r.Dx(), r.Dy())
+	ox, oy := float32(origin.X-r.Min.X), float32(origin.Y-r.Min.Y)
+	start := 0
+	for _, end := range g.Ends {
+		addContour(z, g.Points[start:end], ox, oy)
+		start = end
+	}
+	z.Draw(dst, r, image.White, image.Point{})
+}
+
+// addContour adds the quadratic contour of a TrueType glyph to the path, the middle of two off-curve points is on the curve
+func addContour(z *vector.Rasterizer, points []truetype.Point, ox, oy float32) {
+	n := len(points)
+	if n == 0 {
+		return
+	}
+	at := func(i int) (float32, float32) {
+		p := points[i%n]
+		return ox + float32(p.X)/64, oy - float32(p.Y)/64
+	}
+	onCurve := func(i int) bool { return points[i%n].Flags&0x01 != 0 }
+
+	// the contour starts on its first on-curve point, or between its last and first points
+	first := 0
+	for first < n && !onCurve(first) {
+		first++
+	}
+	var sx, sy float32
+	count := n - 1
+	if first == n {
+		x0, y0 := at(n - 1)
+		x1, y1 := at(0)
+		sx, sy = (x0+x1)/2, (y0+y1)/2
+		first, count = -1, n
+	} else {
+		sx, sy = at(first)
+	}
+	z.MoveTo(sx, sy)
+	var (
+		cx, cy  float32
+		control bool
+	)
+	for i := first + 1; i <= first+count; i++ {
+		x, y := at(i)
+		switch {
+		case onCurve(i) && control:
+			z.QuadTo(cx, cy, x, y)
+			control = false
+		case onCurve(i):
+			z.LineTo(x, y)
+		case control:
+			z.QuadTo(cx, cy, (cx+x)/2, (cy+y)/2)
+			cx, cy = x, y
+		default:
+			cx, cy, control = x, y, true
+		}
+	}
+	if control {
+		z.QuadTo(cx, cy, sx, sy)
+	}
+	z.ClosePath()
+}
+
+// place packs a glyph of w x h pixels with the padding around it, the top left of the glyph in the page.
+// when the pages are full, the glyph drawn the longest time ago with room for it is evicted,
+// or the page drawn the longest time ago when none has room. the glyphs used in this frame are kept
+func (a *FontAtlas) place(w, h int) (index, x, y int, ok bool) {
+	w, h = w+2*a.padding, h+2*a.padding
+	try := func(i int) bool {
+		page := a.Pages[i]
+		if free, fits := page.fitFree(w, h); fits {
+			px, py := page.takeFree(free, w, h)
+			index, x, y = i, px+a.padding, py+a.padding
+			return true
+		}
+		node, px, py, fits := page.fit(w, h)
+		if fits {
+			page.insert(node, px, py, w, h)
+			page.grow(py + h)
+			index, x, y = i, px+a.padding, py+a.padding
+		}
+		return fits
+	}
+	for i := range a.Pages {
+		if try(i) {
+			return index, x, y, true
+		}
+	}
+	if len(a.Pages) < fontMaxPages {
+		a.Pages = append(a.Pages, newAtlasPage())
+		return index, x, y, try(len(a.Pages) - 1)
+	}
+
+	var lru *glyphSlot
+	evicted := truetype.Index(0)
+	for glyph, slot := range a.slots {
+		if slot.lastUsed == fontFrame || slot.rect.Dx() < w || slot.rect.Dy() < h {
+			continue
+		}
+		if lru == nil || slot.lastUsed < lru.lastUsed || slot.lastUsed == lru.lastUsed && glyph < evicted {
+			lru, evicted = slot, glyph
+		}
+	}
+	if lru != nil {
+		a.evictGlyph(evicted)
+		return index, x, y, try(lru.page)
+	}
+	page := -1
+	for i, p := range a.Pages {
+		if p.lastUsed != fontFrame && (page < 0 || p.lastUsed < a.Pages[page].lastUsed) {
+			page = i
+		}
+	}
+	if page < 0 {
+		return
+	}
+	a.evictPage(page)
+	return index, x, y, try(page)
+}
+
+// use keeps the glyph in the atlas in this frame
+func (a *FontAtlas) use(glyph truetype.Index) {
+	if slot, ok := a.slots[glyph]; ok {
+		slot.lastUsed = fontFrame
+	}
+}
+
+// evictGlyph removes the glyph from the atlas, its rectangle is emptied for another glyph
+func (a *FontAtlas) evictGlyph(glyph truetype.Index) {
+	slot := a.slots[glyph]
+	page := a.Pages[slot.page]
+	draw.Draw(page.Image, slot.rect, image.Transparent, image.Point{}, draw.Src)
+	page.free = append(page.free, slot.rect)
+	page.dirty = page.dirty.Union(slot.rect)
+	page.generation++
+	a.remove(glyph)
+}
+
+// evictPage empties the page, its glyphs are removed from the atlas
+func (a *FontAtlas) evictPage(index int) {
+	for glyph, slot := range a.slots {
+		if slot.page == index {
+			a.remove(glyph)
+		}
+	}
+	a.Pages[index].clear()
+}
+
+func (a *FontAtlas) remove(glyph truetype.Index) {
+	delete(a.slots, glyph)
+	delete(a.Page, glyph)
+	delete(a.XLocation, glyph)
+	delete(a.YLocation, glyph)
+	delete(a.Width, glyph)
+	delete(a.Height, glyph)
+	delete(a.LeftSide, glyph)
+	delete(a.RightSide, glyph)
+	delete(a.OffsetY, glyph)
+}
@@ -0,0 +1,28 @@
+//go:build (darwin || linux || windows) && !ios && !android && !js && !nogl
+// +build darwin linux windows
+// +build !ios
+// +build !android
+// +build !js
+// +build !nogl
+
+package engoutil
+
+import (
+	"image"
+
+	gl2 "github.com/go-gl/gl/v2.1/gl"
+)
+
+// engo.Gl of the desktop has no TexSubImage2D and CopyTexSubImage2D
+
+// texSubImage2D uploads the rectangle of the image to the bound texture
+func texSubImage2D(img *image.NRGBA, r image.Rectangle) {
+	pix := packRect(img, r)
+	gl2.TexSubImage2D(gl2.TEXTURE_2D, 0, int32(r.Min.X), int32(r.Min.Y), int32(r.Dx()), int32(r.Dy()),
+		gl2.RGBA, gl2.UNSIGNED_BYTE, gl2.Ptr(pix))
+}
+
+// copyTexSubImage2D copies the pixels of the framebuffer to the bound texture, at the same place
+func copyTexSubImage2D(width, height int) {
+	gl2.CopyTexSubImage2D(gl2.TEXTURE_2D, 0, 0, 0, 0, 0, int32(width), int32(height))
+}
@@ -0,0 +1,25 @@
+//go:build js && !nogl
+// +build js,!nogl
+
+package engoutil
+
+import (
+	"image"
+	"syscall/js"
+
+	"github.com/EngoEngine/engo"
+)
+
+// texSubImage2D uploads the rectangle of the image to the bound texture
+func texSubImage2D(img *image.NRGBA, r image.Rectangle) {
+	pix := packRect(img, r)
+	data := js.Global().Get("Uint8ClampedArray").New(len(pix))
+	js.CopyBytesToJS(data, pix)
+	source := js.Global().Get("ImageData").New(data, r.Dx(), r.Dy())
+	engo.Gl.TexSubImage2D(engo.Gl.TEXTURE_2D, 0, r.Min.X, r.Min.Y, engo.Gl.RGBA, engo.Gl.UNSIGNED_BYTE, source)
+}
+
+// copyTexSubImage2D copies the pixels of the framebuffer to the bound texture, at the same place
+func copyTexSubImage2D(width, height int) {
+	engo.Gl.CopyTexSubImage2D(engo.Gl.TEXTURE_2D, 0, 0, 0, 0, 0, width, height)
+}
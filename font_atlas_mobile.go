@@ -0,0 +1,23 @@
+//go:build (android || ios) && !nogl
+// +build android ios
+// +build !nogl
+
+package engoutil
+
+import (
+	"image"
+
+	"github.com/EngoEngine/engo"
+)
+
+// engo.Gl of the mobiles has no usable TexSubImage2D, the whole image is uploaded
+
+// texSubImage2D uploads the rectangle of the image to the bound texture
+func texSubImage2D(img *image.NRGBA, _ image.Rectangle) {
+	engo.Gl.TexImage2D(engo.Gl.TEXTURE_2D, 0, engo.Gl.RGBA, engo.Gl.RGBA, engo.Gl.UNSIGNED_BYTE, img)
+}
+
+// copyTexSubImage2D copies the pixels of the framebuffer to the bound texture, at the same place
+func copyTexSubImage2D(width, height int) {
+	engo.Gl.CopyTexSubImage2D(engo.Gl.TEXTURE_2D, 0, 0, 0, 0, 0, width, height)
+}
@@ -0,0 +1,10 @@
+//go:build nogl
+// +build nogl
+
+package engoutil
+
+import "image"
+
+func texSubImage2D(*image.NRGBA, image.Rectangle) {}
+
+func copyTexSubImage2D(int, int) {}
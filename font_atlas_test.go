@@ -0,0 +1,94 @@
+package engoutil
+
+import (
+	"image"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+)
+
+func TestAtlasPageSkyline(t *testing.T) {
+	size, pages := fontPageSize, fontMaxPages
+	defer SetFontAtlasPages(size, pages)
+	SetFontAtlasPages(64, 1)
+
+	p := newAtlasPage()
+	for _, c := range []struct {
+		w, h int
+		ok   bool
+		x, y int
+	}{
+		{32, 10, true, 0, 0},
+		{32, 20, true, 32, 0},
+		// the lowest segment wide enough
+		{16, 5, true, 0, 10},
+		{16, 5, true, 16, 10},
+		// on the highest of the segments under it
+		{48, 4, true, 0, 20},
+		{65, 1, false, 0, 0},
+		{64, 41, false, 0, 0},
+		{64, 40, true, 0, 24},
+	} {
+		node, x, y, ok := p.fit(c.w, c.h)
+		if ok != c.ok || ok && (x != c.x || y != c.y) {
+			t.Fatalf("fit %dx%d = (%d, %d) %v, want (%d, %d) %v", c.w, c.h, x, y, ok, c.x, c.y, c.ok)
+		}
+		if ok {
+			p.insert(node, x, y, c.w, c.h)
+		}
+	}
+	if want := []skylineNode{{0, 64, 64}}; len(p.skyline) != 1 || p.skyline[0] != want[0] {
+		t.Errorf("skyline %v, want %v", p.skyline, want)
+	}
+}
+
+func TestFontAtlasEviction(t *testing.T) {
+	size, pages, frame := fontPageSize, fontMaxPages, fontFrame
+	defer func() {
+		SetFontAtlasPages(size, pages)
+		fontFrame = frame
+	}()
+	SetFontAtlasPages(64, 1)
+
+	a := &FontAtlas{Page: make(map[truetype.Index]int), slots: make(map[truetype.Index]*glyphSlot)}
+	add := func(glyph truetype.Index, w, h int) image.Point {
+		index, x, y, ok := a.place(w, h)
+		if !ok {
+			t.Fatalf("glyph %d of %dx%d does not fit", glyph, w, h)
+		}
+		a.Page[glyph] = index
+		a.Pages[index].lastUsed = fontFrame
+		a.slots[glyph] = &glyphSlot{page: index, rect: image.Rect(x, y, x+w, y+h), lastUsed: fontFrame}
+		return image.Pt(x, y)
+	}
+	for glyph := truetype.Index(1); glyph <= 4; glyph++ {
+		fontFrame = uint64(glyph)
+		add(glyph, 32, 32)
+	}
+
+	// the glyph 1 is drawn in this frame, the glyph 2 is the least recently drawn
+	fontFrame = 5
+	a.use(1)
+	if at := add(5, 16, 16); at != a.slots[1].rect.Min.Add(image.Pt(32, 0)) || a.slots[2] != nil {
+		t.Fatalf("glyph 5 at %v, evicted %v, want the place of the glyph 2", at, a.slots[2] == nil)
+	}
+	// the rest of the place of the glyph 2, the smallest free rectangle with room
+	if at := add(6, 32, 16); at != image.Pt(32, 16) {
+		t.Errorf("glyph 6 at %v, want (32, 16)", at)
+	}
+	if at := add(7, 16, 16); at != image.Pt(48, 0) {
+		t.Errorf("glyph 7 at %v, want (48, 0)", at)
+	}
+
+	// no glyph is as large, the page is emptied
+	fontFrame = 6
+	add(8, 40, 40)
+	if len(a.slots) != 1 || a.Page[8] != 0 || len(a.Page) != 1 {
+		t.Errorf("%d glyphs left after emptying the page, want 1", len(a.slots))
+	}
+
+	// the glyphs of this frame are kept
+	if _, _, _, ok := a.place(40, 40); ok {
+		t.Error("glyph of 40x40 evicts a glyph of this frame")
+	}
+}
@@ -0,0 +1,38 @@
+package engoutil
+
+import (
+	"bytes"
+
+	"github.com/EngoEngine/engo"
+	gtfont "github.com/go-text/typesetting/font"
+)
+
+// the faces of the fonts with their data, by url. the texts in them are shaped by HarfBuzz
+var shapingFaces = make(map[string]*gtfont.Face)
+
+// PreloadFont loads the font of the data as engo.Files.LoadReaderData does, and keeps it for the shaper.
+// the texts in the fonts preloaded by engo are not shaped, their characters are drawn one by one,
+// kerned by the kern table
+func PreloadFont(url string, data []byte) error {
+	if err := engo.Files.LoadReaderData(url, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	parseShapingFace(url, data)
+	return nil
+}
+
+func parseShapingFace(url string, data []byte) {
+	if face, err := gtfont.ParseTTF(bytes.NewReader(data)); err == nil {
+		shapingFaces[url] = face
+	} else {
+		warning("%q, the texts are not shaped. Error was: %s", url, err.Error())
+	}
+}
+
+// kern the kerning of the kern table for the pair of characters, in pixels of the font texture of the text
+func (f *Font) kern(prev, char rune) float32 {
+	if f.face == nil {
+		return 0
+	}
+	return float32(f.face.Kern(prev, char)) / 64
+}
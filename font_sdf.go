@@ -0,0 +1,140 @@
+package engoutil
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/EngoEngine/math"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+)
+
+// The signed distance field atlases, one per face for all the sizes.
+// The alpha of the texture is the distance to the edge of the character, 0.5 on the edge,
+// the shader draws the edge crisp at any scale, and the outline and the shadow from the distances.
+const (
+	// the font size the characters are rasterized at, in pixels of the texture
+	sdfFontSize = 48
+	// the distances are measured up to this far from the edges, in pixels of the texture
+	sdfDistance = 8
+)
+
+var sdfAtlasCache = make(map[*truetype.Font]*FontAtlas)
+
+// ratio the size of a pixel of the atlas, in pixels of the font texture of the text. 1 without SDF
+func (f *Font) ratio() float32 {
+	if !f.SDF {
+		return 1
+	}
+	scale := f.scale
+	if scale == 0 {
+		scale = 1
+	}
+	return float32(f.Size) * scale / sdfFontSize
+}
+
+// padding the outline and the blur of the shadow are limited to it, in pixels of the font texture of the text
+func (f *Font) padding() float32 {
+	if !f.SDF {
+		return fontTexturePadding
+	}
+	return sdfDistance * f.ratio()
+}
+
+// updateSDFAtlas the same as updateFontAtlas for the SDF fonts, the metrics are in pixels of the atlas, see ratio
+func (f *Font) updateSDFAtlas(glyphs []truetype.Index) (atlas *FontAtlas) {
+	atlas, initialized := sdfAtlasCache[f.TTF]
+	if !initialized {
+		// the glyphs and the fields around them do not overlap
+		atlas = newFontAtlas(f.TTF, sdfFontSize, font.HintingNone, sdfDistance)
+		atlas.Size, atlas.Distance = sdfFontSize, sdfDistance
+		sdfAtlasCache[f.TTF] = atlas
+		glyphs = append(basicGlyphs(f.TTF), glyphs...)
+	}
+	atlas.update(glyphs)
+	return
+}
+
+// drawDistanceField draws the field of the glyph loaded in the buffer, bounds around its origin,
+// the top left of the glyph at x, y of the page
+func (a *FontAtlas) drawDistanceField(page *AtlasPage, x, y int, bounds image.Rectangle) {
+	mask := image.NewAlpha(image.Rect(0, 0, bounds.Dx()+2*sdfDistance, bounds.Dy()+2*sdfDistance))
+	// draw on baseline
+	drawGlyph(&a.glyphBuf, mask, mask.Rect, image.Pt(sdfDistance-bounds.Min.X, sdfDistance-bounds.Min.Y))
+
+	// the locations are the boxes of the glyphs, the fields are around them
+	field := distanceField(mask)
+	x0, y0 := x-sdfDistance, y-sdfDistance
+	for y := 0; y < mask.Rect.Dy(); y++ {
+		for x := 0; x < mask.Rect.Dx(); x++ {
+			alpha := 0.5 + field[y*mask.Rect.Dx()+x]/(2*sdfDistance)
+			page.Image.SetNRGBA(x0+x, y0+y, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: uint8(math.Clamp(alpha, 0, 1)*0xff + 0.5)})
+		}
+	}
+}
+
+// distanceField the signed distances of the pixels to the edge of the mask, positive inside, in pixels.
+// the 8SSEDT, the offsets to the nearest pixels are propagated in two passes
+func distanceField(mask *image.Alpha) []float32 {
+	w, h := mask.Rect.Dx(), mask.Rect.Dy()
+	// the offsets to the nearest pixel inside, and to the nearest pixel outside
+	inside, outside := make([][2]int, w*h), make([][2]int, w*h)
+	const far = 1 << 12
+	for i := range inside {
+		if mask.Pix[i] >= 0x80 {
+			outside[i] = [2]int{far, far}
+		} else {
+			inside[i] = [2]int{far, far}
+		}
+	}
+	propagateOffsets(inside, w, h)
+	propagateOffsets(outside, w, h)
+
+	field := make([]float32, w*h)
+	for i := range field {
+		field[i] = offsetLength(outside[i]) - offsetLength(inside[i])
+	}
+	return field
+}
+
+func offsetLength(offset [2]int) float32 {
+	return math.Sqrt(float32(offset[0]*offset[0] + offset[1]*offset[1]))
+}
+
+func propagateOffsets(grid [][2]int, w, h int) {
+	compare := func(x, y, ox, oy int) {
+		nx, ny := x+ox, y+oy
+		if nx < 0 || ny < 0 || nx >= w || ny >= h {
+			return
+		}
+		other := grid[ny*w+nx]
+		other[0] += ox
+		other[1] += oy
+		current := &grid[y*w+x]
+		if other[0]*other[0]+other[1]*other[1] < current[0]*current[0]+current[1]*current[1] {
+			*current = other
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			compare(x, y, -1, 0)
+			compare(x, y, 0, -1)
+			compare(x, y, -1, -1)
+			compare(x, y, 1, -1)
+		}
+		for x := w - 1; x >= 0; x-- {
+			compare(x, y, 1, 0)
+		}
+	}
+	for y := h - 1; y >= 0; y-- {
+		for x := w - 1; x >= 0; x-- {
+			compare(x, y, 1, 0)
+			compare(x, y, 0, 1)
+			compare(x, y, -1, 1)
+			compare(x, y, 1, 1)
+		}
+		for x := 0; x < w; x++ {
+			compare(x, y, -1, 0)
+		}
+	}
+}
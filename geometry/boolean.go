@@ -0,0 +1,263 @@
+package geometry
+
+import (
+	"math"
+	"sort"
+
+	"github.com/kayon/engoutil"
+)
+
+type Op uint8
+
+const (
+	OP_UNION Op = iota
+	OP_INTERSECTION
+	OP_DIFFERENCE
+	OP_XOR
+)
+
+// Union the area inside a or b
+func Union(a, b []engoutil.Points) []engoutil.Points {
+	return Clip(OP_UNION, a, b)
+}
+
+// Intersection the area inside both a and b
+func Intersection(a, b []engoutil.Points) []engoutil.Points {
+	return Clip(OP_INTERSECTION, a, b)
+}
+
+// Difference the area inside a and outside b
+func Difference(a, b []engoutil.Points) []engoutil.Points {
+	return Clip(OP_DIFFERENCE, a, b)
+}
+
+// Xor the area inside either a or b, but not both
+func Xor(a, b []engoutil.Points) []engoutil.Points {
+	return Clip(OP_XOR, a, b)
+}
+
+// Simplify removes the self-intersections of the contours, the area is kept by the even-odd rule
+func Simplify(contours []engoutil.Points) []engoutil.Points {
+	rings := toRings(contours)
+	return fromRings(trace(rings, func(p vec) bool { return evenOdd(rings, p) }))
+}
+
+// Clip the boolean operation of the subject and the clip polygons.
+// the edges are split at all the intersections, which is O(n²) of the number of edges.
+func Clip(op Op, subject, clip []engoutil.Points) []engoutil.Points {
+	a, b := toRings(subject), toRings(clip)
+	filled := func(p vec) bool {
+		inA, inB := evenOdd(a, p), evenOdd(b, p)
+		switch op {
+		case OP_UNION:
+			return inA || inB
+		case OP_INTERSECTION:
+			return inA && inB
+		case OP_DIFFERENCE:
+			return inA && !inB
+		case OP_XOR:
+			return inA != inB
+		}
+		return false
+	}
+	return fromRings(trace(append(a, b...), filled))
+}
+
+// the tolerance of the parameters along the segments
+const epsilon = 1e-9
+
+type segment struct{ a, b vec }
+
+// cut is a point where a segment is split, t is its parameter along the segment
+type cut struct {
+	t float64
+	p vec
+}
+
+// trace returns the boundaries of the area filled, made of the edges of the rings.
+// every piece of the edges between two intersections is kept when it is filled on one side only,
+// it is turned to have the filled side on its left, then the pieces are chained into rings.
+func trace(rings [][]vec, filled func(vec) bool) [][]vec {
+	var segments []segment
+	for _, ring := range rings {
+		for i, a := range ring {
+			segments = append(segments, segment{a, ring[(i+1)%len(ring)]})
+		}
+	}
+	cuts := splitSegments(segments)
+
+	type edge struct {
+		a, b vec
+		used bool
+	}
+	var (
+		edges []*edge
+		seen  = make(map[segment]bool)
+		from  = make(map[vec][]*edge)
+	)
+	for i, s := range segments {
+		points := []vec{s.a}
+		for _, c := range cuts[i] {
+			points = append(points, c.p)
+		}
+		points = append(points, s.b)
+		for j := 1; j < len(points); j++ {
+			a, b := points[j-1], points[j]
+			if a == b {
+				continue
+			}
+			// the same piece of two edges is kept once, its sides are tested against all the rings
+			key := segment{a, b}
+			if b.less(a) {
+				key = segment{b, a}
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			d := b.sub(a)
+			mid := a.add(d.mul(0.5))
+			side := vec{-d.y, d.x}.mul(1e-4)
+			left, right := filled(mid.add(side)), filled(mid.sub(side))
+			if left == right {
+				continue
+			}
+			if right {
+				a, b = b, a
+			}
+			e := &edge{a: a, b: b}
+			edges = append(edges, e)
+			from[a] = append(from[a], e)
+		}
+	}
+
+	var result [][]vec
+	for _, first := range edges {
+		if first.used {
+			continue
+		}
+		first.used = true
+		ring := []vec{first.a}
+		current := first
+		for current.b != first.a {
+			// the sharpest turn to the left keeps the rings touching at a point apart
+			var (
+				next  *edge
+				angle = -math.MaxFloat64
+				in    = current.b.sub(current.a)
+			)
+			for _, e := range from[current.b] {
+				if e.used {
+					continue
+				}
+				out := e.b.sub(e.a)
+				if a := math.Atan2(in.cross(out), in.dot(out)); a > angle {
+					next, angle = e, a
+				}
+			}
+			if next == nil {
+				// an open chain left by the rounding errors
+				ring = nil
+				break
+			}
+			next.used = true
+			ring = append(ring, next.a)
+			current = next
+		}
+		if ring = cleanRing(ring); ring != nil {
+			result = append(result, ring)
+		}
+	}
+	return result
+}
+
+// splitSegments returns the intersections along every segment, sorted by their parameters
+func splitSegments(segments []segment) [][]cut {
+	cuts := make([][]cut, len(segments))
+	for i := range segments {
+		for j := i + 1; j < len(segments); j++ {
+			intersect(segments[i], segments[j], &cuts[i], &cuts[j])
+		}
+	}
+	for i := range cuts {
+		c := cuts[i]
+		sort.Slice(c, func(a, b int) bool { return c[a].t < c[b].t })
+	}
+	return cuts
+}
+
+// intersect adds the intersections inside the segments to their cuts.
+// a point near an end of a segment is snapped to it, so both segments are split at the very same point.
+func intersect(s0, s1 segment, c0, c1 *[]cut) {
+	if math.Max(s0.a.x, s0.b.x) < math.Min(s1.a.x, s1.b.x) || math.Max(s1.a.x, s1.b.x) < math.Min(s0.a.x, s0.b.x) ||
+		math.Max(s0.a.y, s0.b.y) < math.Min(s1.a.y, s1.b.y) || math.Max(s1.a.y, s1.b.y) < math.Min(s0.a.y, s0.b.y) {
+		return
+	}
+	r, s := s0.b.sub(s0.a), s1.b.sub(s1.a)
+	q := s1.a.sub(s0.a)
+	den := r.cross(s)
+	lr, ls := r.length(), s.length()
+
+	if math.Abs(den) <= epsilon*lr*ls {
+		if math.Abs(q.cross(r)) > epsilon*lr*lr {
+			// parallel
+			return
+		}
+		// collinear, the ends of each segment inside the other one split it
+		project := func(p vec, seg segment, d vec, into *[]cut) {
+			if t := p.sub(seg.a).dot(d) / d.dot(d); t > epsilon && t < 1-epsilon {
+				*into = append(*into, cut{t, p})
+			}
+		}
+		project(s1.a, s0, r, c0)
+		project(s1.b, s0, r, c0)
+		project(s0.a, s1, s, c1)
+		project(s0.b, s1, s, c1)
+		return
+	}
+
+	t, u := q.cross(s)/den, q.cross(r)/den
+	if t < -epsilon || t > 1+epsilon || u < -epsilon || u > 1+epsilon {
+		return
+	}
+	var p vec
+	switch {
+	case u <= epsilon:
+		p = s1.a
+	case u >= 1-epsilon:
+		p = s1.b
+	case t <= epsilon:
+		p = s0.a
+	case t >= 1-epsilon:
+		p = s0.b
+	default:
+		p = s0.a.add(r.mul(t))
+	}
+	if t > epsilon && t < 1-epsilon {
+		*c0 = append(*c0, cut{t, p})
+	}
+	if u > epsilon && u < 1-epsilon {
+		*c1 = append(*c1, cut{u, p})
+	}
+}
+
+// cleanRing removes the points in the middle of straight lines, nil for a ring without area
+func cleanRing(ring []vec) []vec {
+	for changed := true; changed && len(ring) >= 3; {
+		changed = false
+		for i := 0; i < len(ring) && len(ring) >= 3; i++ {
+			prev, p, next := ring[(i-1+len(ring))%len(ring)], ring[i], ring[(i+1)%len(ring)]
+			d0, d1 := p.sub(prev), next.sub(p)
+			if p == prev || math.Abs(d0.cross(d1)) <= epsilon*d0.length()*d1.length() && d0.dot(d1) >= 0 {
+				ring = append(ring[:i], ring[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	if len(ring) < 3 || ringArea(ring) == 0 {
+		return nil
+	}
+	return ring
+}
@@ -0,0 +1,82 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kayon/engoutil"
+)
+
+func rect(x0, y0, x1, y1 float32) engoutil.Points {
+	return engoutil.Points{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+}
+
+// checkPolygon the area of the polygon, and the outer contours counterclockwise and the holes clockwise
+func checkPolygon(t *testing.T, name string, contours []engoutil.Points, area float32, holes int) {
+	t.Helper()
+	if got := Area(contours); math.Abs(float64(got-area)) > 1e-3 {
+		t.Errorf("%s: area %v, want %v", name, got, area)
+	}
+	negative := 0
+	for _, c := range contours {
+		if Area([]engoutil.Points{c}) < 0 {
+			negative++
+		}
+	}
+	if negative != holes {
+		t.Errorf("%s: %d clockwise contours of %d, want %d holes", name, negative, len(contours), holes)
+	}
+}
+
+func TestClip(t *testing.T) {
+	a := []engoutil.Points{rect(0, 0, 2, 2)}
+	overlapping := []engoutil.Points{rect(1, 1, 3, 3)}
+	touching := []engoutil.Points{rect(2, 0, 3, 2)}
+	inner := []engoutil.Points{rect(0.5, 0.5, 1.5, 1.5)}
+	ring := []engoutil.Points{rect(0, 0, 2, 2), rect(0.5, 0.5, 1.5, 1.5)}
+	// the clockwise contours are the same polygons
+	reversed := []engoutil.Points{{{1, 1}, {1, 3}, {3, 3}, {3, 1}}}
+
+	for _, c := range []struct {
+		name          string
+		op            Op
+		subject, clip []engoutil.Points
+		area          float32
+		contours      int
+		holes         int
+	}{
+		{"union of two squares", OP_UNION, a, overlapping, 7, 1, 0},
+		{"intersection of two squares", OP_INTERSECTION, a, overlapping, 1, 1, 0},
+		{"difference of two squares", OP_DIFFERENCE, a, overlapping, 3, 1, 0},
+		{"xor of two squares", OP_XOR, a, overlapping, 6, 2, 0},
+		{"union of a clockwise square", OP_UNION, a, reversed, 7, 1, 0},
+		{"union of touching squares", OP_UNION, a, touching, 6, 1, 0},
+		{"intersection of touching squares", OP_INTERSECTION, a, touching, 0, 0, 0},
+		{"difference of touching squares", OP_DIFFERENCE, a, touching, 4, 1, 0},
+		{"difference of an inner square", OP_DIFFERENCE, a, inner, 3, 2, 1},
+		{"union of a ring and its hole", OP_UNION, ring, inner, 4, 1, 0},
+	} {
+		got := Clip(c.op, c.subject, c.clip)
+		if len(got) != c.contours {
+			t.Errorf("%s: %d contours %v, want %d", c.name, len(got), got, c.contours)
+		}
+		checkPolygon(t, c.name, got, c.area, c.holes)
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	// a bow tie, the two triangles of the crossing edges
+	bowTie := []engoutil.Points{{{0, 0}, {2, 2}, {2, 0}, {0, 2}}}
+	got := Simplify(bowTie)
+	if len(got) != 2 {
+		t.Errorf("bow tie: %d contours %v, want 2", len(got), got)
+	}
+	checkPolygon(t, "bow tie", got, 2, 0)
+
+	ring := []engoutil.Points{rect(0, 0, 4, 4), rect(1, 1, 3, 3)}
+	got = Simplify(ring)
+	if len(got) != 2 {
+		t.Errorf("ring: %d contours %v, want 2", len(got), got)
+	}
+	checkPolygon(t, "ring", got, 12, 1)
+}
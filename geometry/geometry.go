@@ -0,0 +1,160 @@
+// Package geometry works on the outlines of polygons: boolean operations and offsetting.
+//
+// A polygon is a list of contours, the contours inside others are holes (the even-odd rule),
+// the same as the contours of engoutil.NewPolygonContours.
+// The results have the outer contours counterclockwise (a positive area) and the holes clockwise,
+// they are in the coordinates of the arguments, see Normalize to make a polygon shape of them.
+package geometry
+
+import (
+	"math"
+
+	"github.com/kayon/engoutil"
+)
+
+// vec is a point in double precision, the intersections are computed with it
+type vec struct{ x, y float64 }
+
+func (a vec) add(b vec) vec         { return vec{a.x + b.x, a.y + b.y} }
+func (a vec) sub(b vec) vec         { return vec{a.x - b.x, a.y - b.y} }
+func (a vec) mul(k float64) vec     { return vec{a.x * k, a.y * k} }
+func (a vec) dot(b vec) float64     { return a.x*b.x + a.y*b.y }
+func (a vec) cross(b vec) float64   { return a.x*b.y - a.y*b.x }
+func (a vec) length() float64       { return math.Hypot(a.x, a.y) }
+func (a vec) normalize() vec        { return a.mul(1 / a.length()) }
+func (a vec) point() engoutil.Point { return engoutil.Point{float32(a.x), float32(a.y)} }
+func toVec(p engoutil.Point) vec    { return vec{float64(p[0]), float64(p[1])} }
+func (a vec) less(b vec) bool       { return a.x < b.x || a.x == b.x && a.y < b.y }
+
+// Bounds the bounding box of the contours
+func Bounds(contours []engoutil.Points) (min, max engoutil.Point) {
+	first := true
+	for _, c := range contours {
+		for _, p := range c {
+			if first {
+				min, max, first = p, p, false
+				continue
+			}
+			min[0], min[1] = float32(math.Min(float64(min[0]), float64(p[0]))), float32(math.Min(float64(min[1]), float64(p[1])))
+			max[0], max[1] = float32(math.Max(float64(max[0]), float64(p[0]))), float32(math.Max(float64(max[1]), float64(p[1])))
+		}
+	}
+	return
+}
+
+// Normalize returns the contours relative to their bounds, with the position and the size of the bounds.
+// they are the arguments of engoutil.NewPolygonContours:
+//
+//	x, y, w, h, contours := geometry.Normalize(geometry.Union(a, b))
+//	shape := engoutil.NewPolygonContours(x, y, w, h, 1, contours, stroke, fill)
+func Normalize(contours []engoutil.Points) (x, y, width, height float32, normalized []engoutil.Points) {
+	min, max := Bounds(contours)
+	x, y, width, height = min[0], min[1], max[0]-min[0], max[1]-min[1]
+	sx, sy := float32(0), float32(0)
+	if width > 0 {
+		sx = 1 / width
+	}
+	if height > 0 {
+		sy = 1 / height
+	}
+	normalized = make([]engoutil.Points, len(contours))
+	for i, c := range contours {
+		normalized[i] = make(engoutil.Points, len(c))
+		for j, p := range c {
+			normalized[i][j] = engoutil.Point{(p[0] - x) * sx, (p[1] - y) * sy}
+		}
+	}
+	return
+}
+
+// Scale multiplies the points by the size, the contours relative to the size of a polygon shape become pixels
+func Scale(contours []engoutil.Points, width, height float32) []engoutil.Points {
+	scaled := make([]engoutil.Points, len(contours))
+	for i, c := range contours {
+		scaled[i] = make(engoutil.Points, len(c))
+		for j, p := range c {
+			scaled[i][j] = engoutil.Point{p[0] * width, p[1] * height}
+		}
+	}
+	return scaled
+}
+
+// Area the signed area of the polygon, the holes are subtracted when they are clockwise
+func Area(contours []engoutil.Points) float32 {
+	var area float64
+	for _, ring := range toRings(contours) {
+		area += ringArea(ring)
+	}
+	return float32(area)
+}
+
+// toRings skips the repeated points and the closing point, and the contours of less than 3 points
+func toRings(contours []engoutil.Points) [][]vec {
+	rings := make([][]vec, 0, len(contours))
+	for _, c := range contours {
+		ring := make([]vec, 0, len(c))
+		for _, p := range c {
+			if v := toVec(p); len(ring) == 0 || v != ring[len(ring)-1] {
+				ring = append(ring, v)
+			}
+		}
+		if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+			ring = ring[:len(ring)-1]
+		}
+		if len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+	}
+	return rings
+}
+
+func fromRings(rings [][]vec) []engoutil.Points {
+	contours := make([]engoutil.Points, len(rings))
+	for i, ring := range rings {
+		contours[i] = make(engoutil.Points, len(ring))
+		for j, v := range ring {
+			contours[i][j] = v.point()
+		}
+	}
+	return contours
+}
+
+func ringArea(ring []vec) float64 {
+	var area float64
+	for i, a := range ring {
+		area += a.cross(ring[(i+1)%len(ring)])
+	}
+	return area / 2
+}
+
+// evenOdd reports whether p is inside an odd number of rings
+func evenOdd(rings [][]vec, p vec) bool {
+	inside := false
+	for _, ring := range rings {
+		for i, a := range ring {
+			b := ring[(i+1)%len(ring)]
+			if (a.y > p.y) != (b.y > p.y) && p.x < a.x+(p.y-a.y)*(b.x-a.x)/(b.y-a.y) {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// winding the winding number of the rings around p, counterclockwise rings count positive
+func winding(rings [][]vec, p vec) int {
+	w := 0
+	for _, ring := range rings {
+		for i, a := range ring {
+			b := ring[(i+1)%len(ring)]
+			if a.y <= p.y {
+				if b.y > p.y && b.sub(a).cross(p.sub(a)) > 0 {
+					w++
+				}
+			} else if b.y <= p.y && b.sub(a).cross(p.sub(a)) < 0 {
+				w--
+			}
+		}
+	}
+	return w
+}
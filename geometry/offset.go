@@ -0,0 +1,90 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/kayon/engoutil"
+)
+
+// A miter longer than MiterLimit * delta falls back to bevel, the same as the strokes
+const MiterLimit = 4
+
+// Offset grows the polygon by delta, or shrinks it by a negative delta.
+// the holes shrink while the outer contours grow. round joins are within a quarter of a unit, as pixels.
+func Offset(contours []engoutil.Points, delta float32, join engoutil.LineJoin) []engoutil.Points {
+	rings := toRings(contours)
+	// the outer contours counterclockwise, the holes clockwise, without self-intersections
+	rings = trace(rings, func(p vec) bool { return evenOdd(rings, p) })
+	if delta == 0 {
+		return fromRings(rings)
+	}
+	raw := make([][]vec, 0, len(rings))
+	for _, ring := range rings {
+		raw = append(raw, offsetRing(ring, float64(delta), join))
+	}
+	// the loops turned over by a corner or a shrinking are not counted
+	return fromRings(trace(raw, func(p vec) bool { return winding(raw, p) > 0 }))
+}
+
+// offsetRing moves the edges of the ring by d to their right, outside of the area.
+// the concave corners go back through the vertex, the loops they make are removed by the winding rule.
+func offsetRing(ring []vec, d float64, join engoutil.LineJoin) []vec {
+	n := len(ring)
+	out := make([]vec, 0, n*2)
+	for i, p := range ring {
+		prev, next := ring[(i-1+n)%n], ring[(i+1)%n]
+		d0, d1 := p.sub(prev).normalize(), next.sub(p).normalize()
+		n0, n1 := vec{d0.y, -d0.x}, vec{d1.y, -d1.x}
+		a, b := p.add(n0.mul(d)), p.add(n1.mul(d))
+		cross, dot := d0.cross(d1), d0.dot(d1)
+
+		if math.Abs(cross) < epsilon && dot > 0 {
+			// straight through
+			out = append(out, a)
+			continue
+		}
+		if cross*d <= 0 {
+			out = append(out, a, p, b)
+			continue
+		}
+		switch join {
+		case engoutil.LINE_JOIN_ROUND:
+			from, sweep := math.Atan2(n0.y, n0.x), math.Atan2(cross, dot)
+			if d < 0 {
+				from += math.Pi
+			}
+			r := math.Abs(d)
+			steps := arcSegments(r, sweep)
+			out = append(out, a)
+			for k := 1; k < steps; k++ {
+				angle := from + sweep*float64(k)/float64(steps)
+				out = append(out, p.add(vec{math.Cos(angle), math.Sin(angle)}.mul(r)))
+			}
+			out = append(out, b)
+			continue
+		case engoutil.LINE_JOIN_MITER:
+			m := n0.add(n1).normalize()
+			if cos := m.dot(n0); cos > 1.0/MiterLimit {
+				out = append(out, p.add(m.mul(d/cos)))
+				continue
+			}
+		}
+		out = append(out, a, b)
+	}
+	return out
+}
+
+// arcSegments returns the number of segments keeping the arc within a quarter unit
+func arcSegments(radius, sweep float64) int {
+	if radius <= 0.25 {
+		return 1
+	}
+	n := int(math.Ceil(math.Abs(sweep) / (2 * math.Acos(1-0.25/radius))))
+	if n < 1 {
+		return 1
+	}
+	if n > 128 {
+		return 128
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kayon/engoutil"
+)
+
+func TestOffset(t *testing.T) {
+	square := []engoutil.Points{rect(0, 0, 10, 10)}
+	lShape := []engoutil.Points{{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}}
+	ring := []engoutil.Points{rect(0, 0, 10, 10), rect(2, 2, 8, 8)}
+
+	for _, c := range []struct {
+		name     string
+		contours []engoutil.Points
+		delta    float32
+		join     engoutil.LineJoin
+		area     float32
+		// the round joins are inside the arcs by a quarter of a unit, the area is smaller by the perimeter of the arcs times that
+		tolerance float32
+		holes     int
+	}{
+		{"miter square", square, 1, engoutil.LINE_JOIN_MITER, 144, 1e-3, 0},
+		{"bevel square", square, 1, engoutil.LINE_JOIN_BEVEL, 142, 1e-3, 0},
+		{"round square", square, 1, engoutil.LINE_JOIN_ROUND, 140 + math.Pi, 2 * math.Pi / 4, 0},
+		{"shrunk square", square, -1, engoutil.LINE_JOIN_ROUND, 64, 1e-3, 0},
+		{"vanished square", square, -6, engoutil.LINE_JOIN_MITER, 0, 1e-3, 0},
+		// the concave corner is the same for all the joins
+		{"miter L-shape", lShape, 0.1, engoutil.LINE_JOIN_MITER, 3.84, 1e-3, 0},
+		{"bevel L-shape", lShape, 0.1, engoutil.LINE_JOIN_BEVEL, 3.84 - 5*0.005, 1e-3, 0},
+		{"shrunk L-shape", lShape, -0.1, engoutil.LINE_JOIN_MITER, 2.24, 1e-3, 0},
+		// the hole shrinks while the outer contour grows
+		{"miter ring", ring, 1, engoutil.LINE_JOIN_MITER, 144 - 16, 1e-3, 1},
+		{"shrunk ring", ring, -1, engoutil.LINE_JOIN_MITER, 64 - 64, 1e-3, 0},
+		{"unchanged ring", ring, 0, engoutil.LINE_JOIN_MITER, 64, 1e-3, 1},
+	} {
+		got := Offset(c.contours, c.delta, c.join)
+		if area := Area(got); math.Abs(float64(area-c.area)) > float64(c.tolerance) {
+			t.Errorf("%s: area %v, want %v", c.name, area, c.area)
+			continue
+		}
+		checkPolygon(t, c.name, got, Area(got), c.holes)
+	}
+}
@@ -0,0 +1,91 @@
+package engoutil
+
+import (
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/gl"
+)
+
+// Group is a list of shapes pushed together, clipped by a mask.
+// The shapes are drawn between two markers of the group, the first one draws the mask into the stencil buffer.
+// Groups with masks do not nest.
+type Group struct {
+	Shapes
+	mask     *Shape
+	inverted bool
+
+	begin, end *Shape
+}
+
+func NewGroup(shapes ...*Shape) *Group {
+	g := &Group{}
+	g.begin = newMaskMarker(g, true)
+	g.end = newMaskMarker(g, false)
+	g.Add(shapes...)
+	return g
+}
+
+// (*Group) Add
+// the shapes added after the group is pushed, are pushed by the next PushGroup, above the end marker
+func (g *Group) Add(shapes ...*Shape) {
+	for _, s := range shapes {
+		if s == nil {
+			continue
+		}
+		s.group = g
+		g.Shapes = append(g.Shapes, s)
+	}
+}
+
+// (*Group) SetMask the shapes are only drawn inside the mask.
+// the mask is not drawn, push it to the canvas to see it.
+func (g *Group) SetMask(mask *Shape) {
+	g.mask = mask
+	g.inverted = false
+}
+
+// (*Group) SetInvertedMask the shapes are only drawn outside the mask
+func (g *Group) SetInvertedMask(mask *Shape) {
+	g.mask = mask
+	g.inverted = true
+}
+
+// (*Group) RemoveMask
+func (g *Group) RemoveMask() {
+	g.mask = nil
+}
+
+// (*Group) Mask
+func (g *Group) Mask() (mask *Shape, inverted bool) {
+	return g.mask, g.inverted
+}
+
+// masks reports whether the point of the world is clipped by the mask, the mouse system converts the cursor through the camera
+func (g *Group) masks(p engo.Point) bool {
+	// the shapes drawn without their mask are not clipped
+	if g.mask == nil || !stencilSupported() {
+		return false
+	}
+	return g.mask.contains(p) == g.inverted
+}
+
+func newMaskMarker(g *Group, begin bool) *Shape {
+	s := newShape(0)
+	s.Render.Drawable = &maskMarker{group: g, begin: begin}
+	s.Render.SetShader(maskMarkerShader)
+	return s
+}
+
+var _ common.Drawable = (*maskMarker)(nil)
+
+// maskMarker enables the stencil test before the shapes of the group, and disables it after them
+type maskMarker struct {
+	group *Group
+	begin bool
+}
+
+func (*maskMarker) Texture() *gl.Texture                       { return nil }
+func (*maskMarker) Width() float32                             { return 0 }
+func (*maskMarker) Height() float32                            { return 0 }
+func (*maskMarker) View() (float32, float32, float32, float32) { return 0, 0, 1, 1 }
+func (*maskMarker) Close()                                     {}
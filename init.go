@@ -1,11 +1,8 @@
 package engoutil
 
 import (
-	"bytes"
 	"fmt"
 
-	"github.com/EngoEngine/engo"
-
 	"github.com/kayon/engoutil/assets"
 )
 
@@ -14,10 +11,10 @@ const FontDroidSans = "DroidSansFallbackFull.ttf"
 
 func init() {
 	var err error
-	if err = engo.Files.LoadReaderData(Font04b08, bytes.NewReader(assets.Font04b08)); err != nil {
+	if err = PreloadFont(Font04b08, assets.Font04b08); err != nil {
 		panic(fmt.Sprintf("unable to load %q! Error was: ", Font04b08) + err.Error())
 	}
-	if err = engo.Files.LoadReaderData(FontDroidSans, bytes.NewReader(assets.FontDroidSans)); err != nil {
+	if err = PreloadFont(FontDroidSans, assets.FontDroidSans); err != nil {
 		panic(fmt.Sprintf("unable to load %q! Error was: ", FontDroidSans) + err.Error())
 	}
 }
@@ -1,7 +1,6 @@
 package engoutil
 
 import (
-	"image"
 	"log"
 
 	"github.com/EngoEngine/engo"
@@ -10,16 +9,25 @@ import (
 )
 
 var (
-	TextShader     = &textShader{cameraEnabled: true}
-	TextHUDShader  = &textShader{}
-	ShapeShader    = &shapeShader{cameraEnabled: true}
-	ShapeHUDShader = &shapeShader{}
+	TextShader      = &textShader{cameraEnabled: true}
+	TextHUDShader   = &textShader{}
+	ShapeShader     = &shapeShader{cameraEnabled: true}
+	ShapeHUDShader  = &shapeShader{}
+	ImageShader     = &imageShader{cameraEnabled: true}
+	ImageHUDShader  = &imageShader{}
+	ShadowShader    = &shadowShader{cameraEnabled: true}
+	ShadowHUDShader = &shadowShader{}
+
+	maskMarkerShader = &maskShader{}
 
 	atlasCache = make(map[Font]*FontAtlas)
 
+	// the mode of the blend function set last
+	blendState BlendMode
+
 	bufferSize = 10000
 
-	shaders     = []common.Shader{TextShader, TextHUDShader, ShapeShader, ShapeHUDShader}
+	shaders     = []common.Shader{TextShader, TextHUDShader, ShapeShader, ShapeHUDShader, ImageShader, ImageHUDShader, ShadowShader, ShadowHUDShader, maskMarkerShader}
 	shadersInit bool
 )
 
@@ -33,19 +41,161 @@ func InitShaders() {
 	}
 }
 
+type BlendMode uint8
+
+const (
+	BLEND_NORMAL BlendMode = iota
+	BLEND_ADDITIVE
+	BLEND_MULTIPLY
+	BLEND_SCREEN
+	// BLEND_PREMULTIPLIED the colors of the shape, or its texture, are premultiplied by alpha already
+	BLEND_PREMULTIPLIED
+)
+
+// blending is embedded in the drawables of the shaders, the mode of SetBlendMode
+type blending struct {
+	blend BlendMode
+}
+
+func (b blending) blendMode() BlendMode         { return b.blend }
+func (b *blending) setBlendMode(mode BlendMode) { b.blend = mode }
+
+// resetBlendMode is called by the Pre of the shaders, after UseProgram.
+// The shaders output premultiplied colors, so every mode is one blend function.
+func resetBlendMode(premultiply *gl.UniformLocation) {
+	blendState = BLEND_NORMAL
+	engo.Gl.BlendFunc(engo.Gl.ONE, engo.Gl.ONE_MINUS_SRC_ALPHA)
+	engo.Gl.Uniform1i(premultiply, 1)
+}
+
+// setBlendMode is called by the Draw of the shaders.
+// The blend function only changes when the mode differs from the previous shape of the batch.
+func setBlendMode(ren *common.RenderComponent, premultiply *gl.UniformLocation) {
+	mode := BLEND_NORMAL
+	if b, ok := ren.Drawable.(interface{ blendMode() BlendMode }); ok {
+		mode = b.blendMode()
+	}
+	if mode == blendState {
+		return
+	}
+	switch mode {
+	case BLEND_ADDITIVE:
+		engo.Gl.BlendFunc(engo.Gl.ONE, engo.Gl.ONE)
+	case BLEND_MULTIPLY:
+		engo.Gl.BlendFunc(engo.Gl.DST_COLOR, engo.Gl.ONE_MINUS_SRC_ALPHA)
+	case BLEND_SCREEN:
+		engo.Gl.BlendFunc(engo.Gl.ONE, engo.Gl.ONE_MINUS_SRC_COLOR)
+	default:
+		engo.Gl.BlendFunc(engo.Gl.ONE, engo.Gl.ONE_MINUS_SRC_ALPHA)
+	}
+	if (mode == BLEND_PREMULTIPLIED) != (blendState == BLEND_PREMULTIPLIED) {
+		if mode == BLEND_PREMULTIPLIED {
+			engo.Gl.Uniform1i(premultiply, 0)
+		} else {
+			engo.Gl.Uniform1i(premultiply, 1)
+		}
+	}
+	blendState = mode
+}
+
 var _ common.Drawable = (*StippleLine)(nil)
 var _ common.Drawable = (*StippleRect)(nil)
 
+// tessellated drawables are drawn by the shape shader as a list of triangles
+type tessellated interface {
+	common.Drawable
+	triangles() []float32
+}
+
+// dashed drawables are drawn by the shape shader as a list of triangles,
+// every vertex is x, y and the distance along the stroke
+type dashed interface {
+	common.Drawable
+	dashVertices(space *common.SpaceComponent) []float32
+	stipple() Stipple
+}
+
 type Stipple struct {
+	// every bit of the Pattern, starting from the lowest one, covers Factor pixels
 	Factor  int32
 	Pattern uint16
+	// Dashes are the lengths of the dashes and the gaps in pixels, they replace the Pattern.
+	// An odd number of values is repeated, as in SVG.
+	Dashes []float32
+	// Offset moves the pattern backward along the line, in pixels
+	Offset float32
+}
+
+// The most dashes and gaps of a pattern, the shader compares them as 4 vec4
+const maxDashes = 16
+
+// runs returns the lengths of the alternating dashes and gaps, starting with a dash,
+// and the offset to the start of the first dash. nil runs draw a solid line.
+func (st Stipple) runs() (runs []float32, offset float32) {
+	offset = st.Offset
+	if len(st.Dashes) > 0 {
+		runs = st.Dashes
+		if len(runs)%2 == 1 {
+			runs = append(runs[:len(runs):len(runs)], runs...)
+		}
+		if len(runs) > maxDashes {
+			warning("Stipple, %d dashes exceeds the limit of %d", len(runs), maxDashes)
+			runs = runs[:maxDashes]
+		}
+		var period float32
+		for _, v := range runs {
+			if v < 0 {
+				return nil, 0
+			}
+			period += v
+		}
+		if period == 0 {
+			return nil, 0
+		}
+		return runs, offset
+	}
+	switch st.Pattern {
+	case 0xFFFF:
+		return nil, 0
+	case 0:
+		// an empty dash, nothing is drawn
+		return []float32{0, 1}, 0
+	}
+
+	factor := float32(st.Factor)
+	if factor < 1 {
+		factor = 1
+	}
+	bit := func(i int) bool { return st.Pattern>>uint(i%16)&1 == 1 }
+	// start from a dash following a gap, so the runs begin with a dash and end with a gap
+	start := 0
+	for i := 0; i < 16; i++ {
+		if bit(i) && !bit(i+15) {
+			start = i
+			break
+		}
+	}
+	offset -= float32(start) * factor
+	for i := 0; i < 16; i++ {
+		if on := bit(start + i); len(runs) > 0 && on == ((len(runs)-1)%2 == 0) {
+			runs[len(runs)-1] += factor
+		} else {
+			runs = append(runs, factor)
+		}
+	}
+	return runs, offset
 }
 
 // StippleLine
+// every two points are a segment, connected segments are joined and keep the pattern going
 type StippleLine struct {
 	BorderWidth float32
 	Points      []engo.Point
 	Stipple     Stipple
+
+	// set by the Shape, tessellated on every draw when nil
+	vertices []float32
+	blending
 }
 
 func (StippleLine) Texture() *gl.Texture                       { return nil }
@@ -58,6 +208,10 @@ func (StippleLine) Close()                                     {}
 type StippleRect struct {
 	BorderWidth float32
 	Stipple     Stipple
+
+	// set by the Shape, tessellated on every draw when nil
+	vertices []float32
+	blending
 }
 
 func (StippleRect) Texture() *gl.Texture                       { return nil }
@@ -66,6 +220,23 @@ func (StippleRect) Height() float32                            { return 0 }
 func (StippleRect) View() (float32, float32, float32, float32) { return 0, 0, 1, 1 }
 func (StippleRect) Close()                                     {}
 
+func (l StippleLine) stipple() Stipple { return l.Stipple }
+func (r StippleRect) stipple() Stipple { return r.Stipple }
+
+func (l StippleLine) dashVertices(*common.SpaceComponent) []float32 {
+	if l.vertices == nil {
+		return strokeStippleLine(l.Points, l.BorderWidth)
+	}
+	return l.vertices
+}
+
+func (r StippleRect) dashVertices(space *common.SpaceComponent) []float32 {
+	if r.vertices == nil {
+		return strokeStippleRect(space.Width, space.Height, r.BorderWidth)
+	}
+	return r.vertices
+}
+
 func setBufferValue(buffer []float32, index int, value float32, changed *bool) {
 	if buffer[index] != value {
 		buffer[index] = value
@@ -73,17 +244,6 @@ func setBufferValue(buffer []float32, index int, value float32, changed *bool) {
 	}
 }
 
-func resizeNRGBAHeight(nrgba *image.NRGBA, h float32) {
-	height := int(h)
-	if height < nrgba.Rect.Max.Y {
-		return
-	}
-	var b []uint8
-	b, nrgba.Pix = nrgba.Pix, make([]uint8, len(nrgba.Pix)+height*nrgba.Rect.Max.X*4)
-	copy(nrgba.Pix, b)
-	nrgba.Rect.Max.Y = height
-}
-
 func unsupportedType(v interface{}) {
 	warning("type %T not supported", v)
 }
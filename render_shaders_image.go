@@ -0,0 +1,325 @@
+package engoutil
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/gl"
+	"github.com/EngoEngine/math"
+)
+
+type imageShader struct {
+	program *gl.Program
+
+	indicesRectangles    []uint16
+	indicesRectanglesVBO *gl.Buffer
+
+	inPosition  int
+	inTexCoords int
+
+	matrixProjection *gl.UniformLocation
+	matrixView       *gl.UniformLocation
+	matrixModel      *gl.UniformLocation
+	uf_Color         *gl.UniformLocation
+	uf_Premultiply   *gl.UniformLocation
+	uf_Mask          *gl.UniformLocation
+
+	projectionMatrix []float32
+	viewMatrix       []float32
+	modelMatrix      []float32
+
+	camera        *common.CameraSystem
+	cameraEnabled bool
+
+	lastBuffer  *gl.Buffer
+	lastTexture *gl.Texture
+}
+
+// the filters last set on the textures of the images, the filter is a parameter of the texture
+var textureFilters = make(map[*gl.Texture]ImageFilter)
+
+func (l *imageShader) Setup(*ecs.World) error {
+	var err error
+	l.program, err = common.LoadShader(`
+attribute vec2 in_Position;
+attribute vec2 in_TexCoords;
+
+uniform mat3 matrixProjection;
+uniform mat3 matrixView;
+uniform mat3 matrixModel;
+
+varying vec2 var_TexCoords;
+
+void main() {
+  var_TexCoords = in_TexCoords;
+
+  vec3 matr = matrixProjection * matrixView * matrixModel * vec3(in_Position, 1.0);
+  gl_Position = vec4(matr.xy, 0, matr.z);
+}
+`, `
+#ifdef GL_ES
+#define LOWP lowp
+precision mediump float;
+#else
+#define LOWP
+#endif
+
+varying vec2 var_TexCoords;
+
+uniform sampler2D uf_Texture;
+uniform vec4 uf_Color;
+// zero when the colors are premultiplied already
+uniform bool uf_Premultiply;
+// the mask of a group is drawn into the stencil, without its transparent pixels
+uniform bool uf_Mask;
+
+void main (void) {
+  gl_FragColor = uf_Color * texture2D(uf_Texture, var_TexCoords);
+  if (uf_Mask && gl_FragColor.a <= 0.5) {
+    discard;
+  }
+  if (uf_Premultiply) {
+    gl_FragColor.rgb *= gl_FragColor.a;
+  }
+}`)
+
+	if err != nil {
+		return err
+	}
+
+	// Create and populate indices buffer
+	l.indicesRectangles = make([]uint16, 6*bufferSize)
+	for i, j := 0, 0; i < bufferSize*6; i, j = i+6, j+4 {
+		l.indicesRectangles[i+0] = uint16(j + 0)
+		l.indicesRectangles[i+1] = uint16(j + 1)
+		l.indicesRectangles[i+2] = uint16(j + 2)
+		l.indicesRectangles[i+3] = uint16(j + 0)
+		l.indicesRectangles[i+4] = uint16(j + 2)
+		l.indicesRectangles[i+5] = uint16(j + 3)
+	}
+	l.indicesRectanglesVBO = engo.Gl.CreateBuffer()
+	engo.Gl.BindBuffer(engo.Gl.ELEMENT_ARRAY_BUFFER, l.indicesRectanglesVBO)
+	engo.Gl.BufferData(engo.Gl.ELEMENT_ARRAY_BUFFER, l.indicesRectangles, engo.Gl.STATIC_DRAW)
+
+	// Define things that should be read from the texture buffer
+	l.inPosition = engo.Gl.GetAttribLocation(l.program, "in_Position")
+	l.inTexCoords = engo.Gl.GetAttribLocation(l.program, "in_TexCoords")
+
+	// Define things that should be set per draw
+	l.matrixProjection = engo.Gl.GetUniformLocation(l.program, "matrixProjection")
+	l.matrixView = engo.Gl.GetUniformLocation(l.program, "matrixView")
+	l.matrixModel = engo.Gl.GetUniformLocation(l.program, "matrixModel")
+	l.uf_Color = engo.Gl.GetUniformLocation(l.program, "uf_Color")
+	l.uf_Premultiply = engo.Gl.GetUniformLocation(l.program, "uf_Premultiply")
+	l.uf_Mask = engo.Gl.GetUniformLocation(l.program, "uf_Mask")
+
+	l.projectionMatrix = make([]float32, 9)
+	l.projectionMatrix[8] = 1
+
+	l.viewMatrix = make([]float32, 9)
+	l.viewMatrix[0] = 1
+	l.viewMatrix[4] = 1
+	l.viewMatrix[8] = 1
+
+	l.modelMatrix = make([]float32, 9)
+	l.modelMatrix[0] = 1
+	l.modelMatrix[4] = 1
+	l.modelMatrix[8] = 1
+
+	return nil
+}
+
+func (l *imageShader) Pre() {
+	engo.Gl.Enable(engo.Gl.BLEND)
+
+	// Bind shader and buffer, enable attributes
+	engo.Gl.UseProgram(l.program)
+	resetBlendMode(l.uf_Premultiply)
+	resetMask(l.uf_Mask)
+	engo.Gl.BindBuffer(engo.Gl.ELEMENT_ARRAY_BUFFER, l.indicesRectanglesVBO)
+	engo.Gl.EnableVertexAttribArray(l.inPosition)
+	engo.Gl.EnableVertexAttribArray(l.inTexCoords)
+
+	if engo.ScaleOnResize() {
+		l.projectionMatrix[0] = 1 / (engo.GameWidth() / 2)
+		l.projectionMatrix[4] = 1 / (-engo.GameHeight() / 2)
+	} else {
+		l.projectionMatrix[0] = 1 / (engo.CanvasWidth() / (2 * engo.CanvasScale()))
+		l.projectionMatrix[4] = 1 / (-engo.CanvasHeight() / (2 * engo.CanvasScale()))
+	}
+
+	if l.cameraEnabled {
+		l.viewMatrix[1], l.viewMatrix[0] = math.Sincos(l.camera.Angle() * math.Pi / 180)
+		l.viewMatrix[3] = -l.viewMatrix[1]
+		l.viewMatrix[4] = l.viewMatrix[0]
+		l.viewMatrix[6] = -l.camera.X()
+		l.viewMatrix[7] = -l.camera.Y()
+		l.viewMatrix[8] = l.camera.Z()
+	} else {
+		l.viewMatrix[6] = -1 / l.projectionMatrix[0]
+		l.viewMatrix[7] = 1 / l.projectionMatrix[4]
+	}
+
+	engo.Gl.UniformMatrix3fv(l.matrixProjection, false, l.projectionMatrix)
+	engo.Gl.UniformMatrix3fv(l.matrixView, false, l.viewMatrix)
+}
+
+func (l *imageShader) updateBuffer(ren *common.RenderComponent, space *common.SpaceComponent) {
+	if size := l.computeBufferSize(ren.Drawable); len(ren.BufferContent) < size {
+		ren.BufferContent = make([]float32, size)
+	}
+
+	if changed := l.generateBufferContent(ren, space, ren.BufferContent); !changed {
+		return
+	}
+
+	if ren.Buffer == nil {
+		ren.Buffer = engo.Gl.CreateBuffer()
+	}
+	engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, ren.Buffer)
+	engo.Gl.BufferData(engo.Gl.ARRAY_BUFFER, ren.BufferContent, engo.Gl.STATIC_DRAW)
+}
+
+func (l *imageShader) computeBufferSize(draw common.Drawable) int {
+	switch d := draw.(type) {
+	case *Image:
+		return 16
+	case *NineSlice:
+		return len(d.quads)
+	default:
+		unsupportedType(draw)
+		return 0
+	}
+}
+
+func (l *imageShader) generateBufferContent(ren *common.RenderComponent, space *common.SpaceComponent, buffer []float32) (changed bool) {
+	switch img := ren.Drawable.(type) {
+	case *Image:
+		u, v, u2, v2 := img.View()
+		setImageQuad(buffer, 0, 0, 0, space.Width, space.Height, u, v, u2, v2, &changed)
+	case *NineSlice:
+		for i, value := range img.quads {
+			setBufferValue(buffer, i, value, &changed)
+		}
+	}
+	return
+}
+
+// setImageQuad sets the 16 values of the quad number `index`
+func setImageQuad(buffer []float32, index int, x, y, w, h, u, v, u2, v2 float32, changed *bool) {
+	index *= 16
+
+	// These four are at 0, 0:
+	setBufferValue(buffer, 0+index, x, changed)
+	setBufferValue(buffer, 1+index, y, changed)
+	setBufferValue(buffer, 2+index, u, changed)
+	setBufferValue(buffer, 3+index, v, changed)
+
+	// These four are at 1, 0:
+	setBufferValue(buffer, 4+index, x+w, changed)
+	setBufferValue(buffer, 5+index, y, changed)
+	setBufferValue(buffer, 6+index, u2, changed)
+	setBufferValue(buffer, 7+index, v, changed)
+
+	// These four are at 1, 1:
+	setBufferValue(buffer, 8+index, x+w, changed)
+	setBufferValue(buffer, 9+index, y+h, changed)
+	setBufferValue(buffer, 10+index, u2, changed)
+	setBufferValue(buffer, 11+index, v2, changed)
+
+	// These four are at 0, 1:
+	setBufferValue(buffer, 12+index, x, changed)
+	setBufferValue(buffer, 13+index, y+h, changed)
+	setBufferValue(buffer, 14+index, u, changed)
+	setBufferValue(buffer, 15+index, v2, changed)
+}
+
+func (l *imageShader) Draw(ren *common.RenderComponent, space *common.SpaceComponent) {
+	var (
+		img   *Image
+		count = 6
+	)
+	switch d := ren.Drawable.(type) {
+	case *Image:
+		img = d
+	case *NineSlice:
+		img = d.Image
+		count = len(d.quads) / 16 * 6
+	default:
+		unsupportedType(ren.Drawable)
+		return
+	}
+	if count == 0 {
+		return
+	}
+
+	if l.lastBuffer != ren.Buffer || ren.Buffer == nil {
+		l.updateBuffer(ren, space)
+
+		engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, ren.Buffer)
+		engo.Gl.VertexAttribPointer(l.inPosition, 2, engo.Gl.FLOAT, false, 16, 0)
+		engo.Gl.VertexAttribPointer(l.inTexCoords, 2, engo.Gl.FLOAT, false, 16, 8)
+		l.lastBuffer = ren.Buffer
+	}
+
+	if img.texture != l.lastTexture {
+		engo.Gl.BindTexture(engo.Gl.TEXTURE_2D, img.texture)
+		l.lastTexture = img.texture
+	}
+
+	// the texture may be shared by images with different filters
+	if last, ok := textureFilters[img.texture]; !ok || last != img.Filter {
+		filter := engo.Gl.LINEAR
+		if img.Filter == IMAGE_FILTER_NEAREST {
+			filter = engo.Gl.NEAREST
+		}
+		engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_MIN_FILTER, filter)
+		engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_MAG_FILTER, filter)
+		textureFilters[img.texture] = img.Filter
+	}
+
+	if space.Rotation != 0 {
+		sin, cos := math.Sincos(space.Rotation * math.Pi / 180)
+
+		l.modelMatrix[0] = ren.Scale.X * engo.GetGlobalScale().X * cos
+		l.modelMatrix[1] = ren.Scale.X * engo.GetGlobalScale().X * sin
+		l.modelMatrix[3] = ren.Scale.Y * engo.GetGlobalScale().Y * -sin
+		l.modelMatrix[4] = ren.Scale.Y * engo.GetGlobalScale().Y * cos
+	} else {
+		l.modelMatrix[0] = ren.Scale.X * engo.GetGlobalScale().X
+		l.modelMatrix[1] = 0
+		l.modelMatrix[3] = 0
+		l.modelMatrix[4] = ren.Scale.Y * engo.GetGlobalScale().Y
+	}
+
+	l.modelMatrix[6] = space.Position.X * engo.GetGlobalScale().X
+	l.modelMatrix[7] = space.Position.Y * engo.GetGlobalScale().Y
+
+	engo.Gl.UniformMatrix3fv(l.matrixModel, false, l.modelMatrix)
+	setBlendMode(ren, l.uf_Premultiply)
+
+	tint := ParseColor(ren.Color).Vec4()
+	engo.Gl.Uniform4f(l.uf_Color, tint[0], tint[1], tint[2], tint[3])
+	engo.Gl.DrawElements(engo.Gl.TRIANGLES, count, engo.Gl.UNSIGNED_SHORT, 0)
+}
+
+func (l *imageShader) Post() {
+	l.lastBuffer = nil
+	l.lastTexture = nil
+
+	// Cleanup
+	engo.Gl.DisableVertexAttribArray(l.inPosition)
+	engo.Gl.DisableVertexAttribArray(l.inTexCoords)
+
+	engo.Gl.BindTexture(engo.Gl.TEXTURE_2D, nil)
+	engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, nil)
+	engo.Gl.BindBuffer(engo.Gl.ELEMENT_ARRAY_BUFFER, nil)
+
+	engo.Gl.Disable(engo.Gl.BLEND)
+}
+
+func (l *imageShader) SetCamera(c *common.CameraSystem) {
+	if l.cameraEnabled {
+		l.camera = c
+	}
+}
@@ -0,0 +1,63 @@
+package engoutil
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/gl"
+)
+
+// drawingMask the mask of a group is being drawn into the stencil
+var drawingMask bool
+
+// resetMask is called by the Pre of the shaders, the transparent pixels of glyphs and images are not part of the mask.
+// The rects and circles of the legacy shader are masks with their whole shape.
+func resetMask(mask *gl.UniformLocation) {
+	if drawingMask {
+		engo.Gl.Uniform1i(mask, 1)
+	} else {
+		engo.Gl.Uniform1i(mask, 0)
+	}
+}
+
+// maskShader draws the markers of the groups, it has no program of its own:
+// the mask is drawn by its shader into the stencil buffer only.
+type maskShader struct{}
+
+func (*maskShader) Setup(*ecs.World) error         { return nil }
+func (*maskShader) Pre()                           {}
+func (*maskShader) Post()                          {}
+func (*maskShader) SetCamera(*common.CameraSystem) {}
+
+func (*maskShader) Draw(ren *common.RenderComponent, _ *common.SpaceComponent) {
+	marker, ok := ren.Drawable.(*maskMarker)
+	if !ok {
+		unsupportedType(ren.Drawable)
+		return
+	}
+	if !marker.begin {
+		engo.Gl.Disable(engo.Gl.STENCIL_TEST)
+		return
+	}
+	g := marker.group
+	if g.mask == nil || !stencilSupported() {
+		return
+	}
+
+	engo.Gl.Enable(engo.Gl.STENCIL_TEST)
+	engo.Gl.Clear(engo.Gl.STENCIL_BUFFER_BIT)
+	stencilWrite()
+
+	mask := g.mask.Render
+	if mask.Scale.X == 0 && mask.Scale.Y == 0 {
+		mask.Scale = engo.Point{X: 1, Y: 1}
+	}
+	shader := mask.Shader()
+	drawingMask = true
+	shader.Pre()
+	shader.Draw(mask, g.mask.Space)
+	shader.Post()
+	drawingMask = false
+
+	stencilTest(g.inverted)
+}
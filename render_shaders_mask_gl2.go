@@ -0,0 +1,32 @@
+//go:build (darwin || linux || windows) && !ios && !android && !js && !nogl
+// +build darwin linux windows
+// +build !ios
+// +build !android
+// +build !js
+// +build !nogl
+
+package engoutil
+
+import gl2 "github.com/go-gl/gl/v2.1/gl"
+
+// engo.Gl of the desktop has no StencilFunc, StencilOp and ColorMask
+
+func stencilSupported() bool { return true }
+
+// stencilWrite the mask sets the stencil to 1 where it is drawn, the colors are kept
+func stencilWrite() {
+	gl2.StencilFunc(gl2.ALWAYS, 1, 0xFF)
+	gl2.StencilOp(gl2.KEEP, gl2.KEEP, gl2.REPLACE)
+	gl2.ColorMask(false, false, false, false)
+}
+
+// stencilTest the shapes of the group are drawn where the stencil is 1, where it is not when inverted
+func stencilTest(inverted bool) {
+	gl2.ColorMask(true, true, true, true)
+	gl2.StencilOp(gl2.KEEP, gl2.KEEP, gl2.KEEP)
+	if inverted {
+		gl2.StencilFunc(gl2.NOTEQUAL, 1, 0xFF)
+	} else {
+		gl2.StencilFunc(gl2.EQUAL, 1, 0xFF)
+	}
+}
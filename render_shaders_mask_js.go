@@ -0,0 +1,42 @@
+//go:build js && !nogl
+// +build js,!nogl
+
+package engoutil
+
+import "github.com/EngoEngine/engo"
+
+// engo.Gl of WebGL has no StencilOp, the context is called directly
+
+// the stencil attribute of the context, checked once
+var stencilChecked, stencilAvailable bool
+
+// stencilSupported the canvas may be created without a stencil buffer, the groups are drawn without their masks then
+func stencilSupported() bool {
+	if !stencilChecked {
+		stencilChecked = true
+		attributes := engo.Gl.Call("getContextAttributes")
+		stencilAvailable = !attributes.IsNull() && attributes.Get("stencil").Truthy()
+		if !stencilAvailable {
+			warning("(*Group) SetMask(), the WebGL context has no stencil buffer, the masks are not supported")
+		}
+	}
+	return stencilAvailable
+}
+
+// stencilWrite the mask sets the stencil to 1 where it is drawn, the colors are kept
+func stencilWrite() {
+	engo.Gl.Call("stencilFunc", engo.Gl.Get("ALWAYS"), 1, 0xFF)
+	engo.Gl.Call("stencilOp", engo.Gl.KEEP, engo.Gl.KEEP, engo.Gl.REPLACE)
+	engo.Gl.ColorMask(false, false, false, false)
+}
+
+// stencilTest the shapes of the group are drawn where the stencil is 1, where it is not when inverted
+func stencilTest(inverted bool) {
+	engo.Gl.ColorMask(true, true, true, true)
+	engo.Gl.Call("stencilOp", engo.Gl.KEEP, engo.Gl.KEEP, engo.Gl.KEEP)
+	if inverted {
+		engo.Gl.StencilFunc(engo.Gl.NOTEQUAL, 1, 0xFF)
+	} else {
+		engo.Gl.StencilFunc(engo.Gl.EQUAL, 1, 0xFF)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build (android || ios) && !nogl
+// +build android ios
+// +build !nogl
+
+package engoutil
+
+// engo.Gl of the mobiles has no StencilOp, the stencil is never written: the groups are drawn without their masks
+
+var stencilWarned bool
+
+func stencilSupported() bool {
+	if !stencilWarned {
+		stencilWarned = true
+		warning("(*Group) SetMask(), the masks are not supported on the mobiles")
+	}
+	return false
+}
+
+func stencilWrite()    {}
+func stencilTest(bool) {}
@@ -0,0 +1,8 @@
+//go:build nogl
+// +build nogl
+
+package engoutil
+
+func stencilSupported() bool { return true }
+func stencilWrite()          {}
+func stencilTest(bool)       {}
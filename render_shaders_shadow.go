@@ -0,0 +1,352 @@
+package engoutil
+
+import (
+	"fmt"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/gl"
+	"github.com/EngoEngine/math"
+)
+
+// The most edges of a polygon shadow, larger polygons cast the shadow of their bounds
+const maxShadowEdges = 32
+
+// uf_Mode of the shadow shader
+const (
+	shadowBox = iota
+	shadowPolygon
+)
+
+// shadowShader draws the shadows of shapeCaster, a Gaussian blur computed analytically:
+// the integral of the blur over a rounded box, or the blurred edge of a polygon.
+type shadowShader struct {
+	program *gl.Program
+
+	inPosition int
+
+	matrixProjection *gl.UniformLocation
+	matrixView       *gl.UniformLocation
+	matrixModel      *gl.UniformLocation
+	uf_Color         *gl.UniformLocation
+	uf_Premultiply   *gl.UniformLocation
+	uf_Mode          *gl.UniformLocation
+	uf_Offset        *gl.UniformLocation
+	uf_Sigma         *gl.UniformLocation
+	uf_Box           *gl.UniformLocation
+	uf_Corner        *gl.UniformLocation
+	uf_Spread        *gl.UniformLocation
+	uf_Edges         [maxShadowEdges]*gl.UniformLocation
+	uf_EdgeCount     *gl.UniformLocation
+
+	projectionMatrix []float32
+	viewMatrix       []float32
+	modelMatrix      []float32
+
+	camera        *common.CameraSystem
+	cameraEnabled bool
+
+	lastBuffer *gl.Buffer
+}
+
+func (l *shadowShader) Setup(*ecs.World) error {
+	var err error
+	l.program, err = common.LoadShader(`
+attribute vec2 in_Position;
+
+uniform mat3 matrixProjection;
+uniform mat3 matrixView;
+uniform mat3 matrixModel;
+
+varying vec2 var_Position;
+
+void main() {
+  var_Position = in_Position;
+
+  vec3 matr = matrixProjection * matrixView * matrixModel * vec3(in_Position, 1.0);
+  gl_Position = vec4(matr.xy, 0, matr.z);
+}
+`, `
+#ifdef GL_ES
+#define LOWP lowp
+precision mediump float;
+#else
+#define LOWP
+#endif
+
+varying vec2 var_Position;
+
+uniform vec4 uf_Color;
+// zero when the colors are premultiplied already
+uniform bool uf_Premultiply;
+// 0: rounded box, 1: polygon
+uniform int uf_Mode;
+uniform vec2 uf_Offset;
+uniform float uf_Sigma;
+// the box from x, y to z, w and the radius of its corners
+uniform vec4 uf_Box;
+uniform float uf_Corner;
+// the polygon grows by spread
+uniform float uf_Spread;
+uniform vec4 uf_Edges[32];
+uniform int uf_EdgeCount;
+
+// an approximation of the error function, within 0.0005
+vec2 erf(vec2 x) {
+  vec2 s = sign(x), a = abs(x);
+  x = 1.0 + (0.278393 + (0.230389 + 0.078108 * (a * a)) * a) * a;
+  x *= x;
+  return s - s / (x * x);
+}
+
+float gaussian(float x, float sigma) {
+  return exp(-(x * x) / (2.0 * sigma * sigma)) / (2.5066283 * sigma);
+}
+
+// the blur along x of the row y of the rounded box, centered at 0, 0
+float boxShadowX(float x, float y, float sigma, float corner, vec2 halfSize) {
+  float delta = min(halfSize.y - corner - abs(y), 0.0);
+  float curved = halfSize.x - corner + sqrt(max(0.0, corner * corner - delta * delta));
+  vec2 integral = 0.5 + 0.5 * erf((x + vec2(-curved, curved)) * (0.7071068 / sigma));
+  return integral.y - integral.x;
+}
+
+// the blur along y is sampled, the rows further than 3 sigma are empty
+float boxShadow(vec2 p, float sigma) {
+  vec2 center = (uf_Box.xy + uf_Box.zw) * 0.5;
+  vec2 halfSize = (uf_Box.zw - uf_Box.xy) * 0.5;
+  p -= center;
+  float low = p.y - halfSize.y;
+  float high = p.y + halfSize.y;
+  float start = clamp(-3.0 * sigma, low, high);
+  float end = clamp(3.0 * sigma, low, high);
+  float dy = (end - start) / 4.0;
+  float y = start + dy * 0.5;
+  float value = 0.0;
+  for (int i = 0; i < 4; i++) {
+    value += boxShadowX(p.x, p.y - y, sigma, uf_Corner, halfSize) * gaussian(y, sigma) * dy;
+    y += dy;
+  }
+  return value;
+}
+
+// the signed distance to the edges, negative inside by the even-odd rule
+float polygonDistance(vec2 p) {
+  float d = 1e10;
+  bool inside = false;
+  for (int i = 0; i < 32; i++) {
+    if (i >= uf_EdgeCount) {
+      break;
+    }
+    vec2 a = uf_Edges[i].xy;
+    vec2 b = uf_Edges[i].zw;
+    vec2 pa = p - a, ba = b - a;
+    float h = clamp(dot(pa, ba) / dot(ba, ba), 0.0, 1.0);
+    d = min(d, length(pa - ba * h));
+    if ((a.y > p.y) != (b.y > p.y) && p.x < (b.x - a.x) * (p.y - a.y) / (b.y - a.y) + a.x) {
+      inside = !inside;
+    }
+  }
+  return inside ? -d : d;
+}
+
+void main (void) {
+  vec2 p = var_Position - uf_Offset;
+  float alpha;
+  if (uf_Mode == 1) {
+    alpha = 0.5 - 0.5 * erf(vec2((polygonDistance(p) - uf_Spread) * 0.7071068 / uf_Sigma)).x;
+  } else {
+    alpha = boxShadow(p, uf_Sigma);
+  }
+  gl_FragColor = vec4(uf_Color.rgb, uf_Color.a * clamp(alpha, 0.0, 1.0));
+  if (uf_Premultiply) {
+    gl_FragColor.rgb *= gl_FragColor.a;
+  }
+}`)
+
+	if err != nil {
+		return err
+	}
+
+	// Define things that should be read from the texture buffer
+	l.inPosition = engo.Gl.GetAttribLocation(l.program, "in_Position")
+
+	// Define things that should be set per draw
+	l.matrixProjection = engo.Gl.GetUniformLocation(l.program, "matrixProjection")
+	l.matrixView = engo.Gl.GetUniformLocation(l.program, "matrixView")
+	l.matrixModel = engo.Gl.GetUniformLocation(l.program, "matrixModel")
+	l.uf_Color = engo.Gl.GetUniformLocation(l.program, "uf_Color")
+	l.uf_Premultiply = engo.Gl.GetUniformLocation(l.program, "uf_Premultiply")
+	l.uf_Mode = engo.Gl.GetUniformLocation(l.program, "uf_Mode")
+	l.uf_Offset = engo.Gl.GetUniformLocation(l.program, "uf_Offset")
+	l.uf_Sigma = engo.Gl.GetUniformLocation(l.program, "uf_Sigma")
+	l.uf_Box = engo.Gl.GetUniformLocation(l.program, "uf_Box")
+	l.uf_Corner = engo.Gl.GetUniformLocation(l.program, "uf_Corner")
+	l.uf_Spread = engo.Gl.GetUniformLocation(l.program, "uf_Spread")
+	for i := range l.uf_Edges {
+		l.uf_Edges[i] = engo.Gl.GetUniformLocation(l.program, fmt.Sprintf("uf_Edges[%d]", i))
+	}
+	l.uf_EdgeCount = engo.Gl.GetUniformLocation(l.program, "uf_EdgeCount")
+
+	l.projectionMatrix = make([]float32, 9)
+	l.projectionMatrix[8] = 1
+
+	l.viewMatrix = make([]float32, 9)
+	l.viewMatrix[0] = 1
+	l.viewMatrix[4] = 1
+	l.viewMatrix[8] = 1
+
+	l.modelMatrix = make([]float32, 9)
+	l.modelMatrix[0] = 1
+	l.modelMatrix[4] = 1
+	l.modelMatrix[8] = 1
+
+	return nil
+}
+
+func (l *shadowShader) Pre() {
+	engo.Gl.Enable(engo.Gl.BLEND)
+
+	// Bind shader and buffer, enable attributes
+	engo.Gl.UseProgram(l.program)
+	resetBlendMode(l.uf_Premultiply)
+	engo.Gl.EnableVertexAttribArray(l.inPosition)
+
+	if engo.ScaleOnResize() {
+		l.projectionMatrix[0] = 1 / (engo.GameWidth() / 2)
+		l.projectionMatrix[4] = 1 / (-engo.GameHeight() / 2)
+	} else {
+		l.projectionMatrix[0] = 1 / (engo.CanvasWidth() / (2 * engo.CanvasScale()))
+		l.projectionMatrix[4] = 1 / (-engo.CanvasHeight() / (2 * engo.CanvasScale()))
+	}
+
+	if l.cameraEnabled {
+		l.viewMatrix[1], l.viewMatrix[0] = math.Sincos(l.camera.Angle() * math.Pi / 180)
+		l.viewMatrix[3] = -l.viewMatrix[1]
+		l.viewMatrix[4] = l.viewMatrix[0]
+		l.viewMatrix[6] = -l.camera.X()
+		l.viewMatrix[7] = -l.camera.Y()
+		l.viewMatrix[8] = l.camera.Z()
+	} else {
+		l.viewMatrix[6] = -1 / l.projectionMatrix[0]
+		l.viewMatrix[7] = 1 / l.projectionMatrix[4]
+	}
+
+	engo.Gl.UniformMatrix3fv(l.matrixProjection, false, l.projectionMatrix)
+	engo.Gl.UniformMatrix3fv(l.matrixView, false, l.viewMatrix)
+}
+
+func (l *shadowShader) updateBuffer(ren *common.RenderComponent, caster *shapeCaster) {
+	if len(ren.BufferContent) < 8 {
+		ren.BufferContent = make([]float32, 8)
+	}
+
+	if changed := l.generateBufferContent(caster, ren.BufferContent); !changed {
+		return
+	}
+
+	if ren.Buffer == nil {
+		ren.Buffer = engo.Gl.CreateBuffer()
+	}
+	engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, ren.Buffer)
+	engo.Gl.BufferData(engo.Gl.ARRAY_BUFFER, ren.BufferContent, engo.Gl.STATIC_DRAW)
+}
+
+// generateBufferContent the quad covering the blur, relative to the position of the shape
+func (l *shadowShader) generateBufferContent(caster *shapeCaster, buffer []float32) (changed bool) {
+	x0, y0, x1, y1 := caster.bounds()
+	setBufferValue(buffer, 0, x0, &changed)
+	setBufferValue(buffer, 1, y0, &changed)
+	setBufferValue(buffer, 2, x1, &changed)
+	setBufferValue(buffer, 3, y0, &changed)
+	setBufferValue(buffer, 4, x1, &changed)
+	setBufferValue(buffer, 5, y1, &changed)
+	setBufferValue(buffer, 6, x0, &changed)
+	setBufferValue(buffer, 7, y1, &changed)
+	return
+}
+
+func (l *shadowShader) Draw(ren *common.RenderComponent, space *common.SpaceComponent) {
+	caster, ok := ren.Drawable.(*shapeCaster)
+	if !ok {
+		unsupportedType(ren.Drawable)
+		return
+	}
+	if caster.Color == nil {
+		return
+	}
+	clr := caster.Color.Vec4()
+	if clr[3] == 0 {
+		return
+	}
+
+	if l.lastBuffer != ren.Buffer || ren.Buffer == nil {
+		l.updateBuffer(ren, caster)
+
+		engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, ren.Buffer)
+		engo.Gl.VertexAttribPointer(l.inPosition, 2, engo.Gl.FLOAT, false, 8, 0)
+		l.lastBuffer = ren.Buffer
+	}
+
+	// the transform of the shape
+	scale := caster.owner.Render.Scale
+	if scale.X == 0 && scale.Y == 0 {
+		scale = engo.Point{X: 1, Y: 1}
+	}
+	if space.Rotation != 0 {
+		sin, cos := math.Sincos(space.Rotation * math.Pi / 180)
+
+		l.modelMatrix[0] = scale.X * engo.GetGlobalScale().X * cos
+		l.modelMatrix[1] = scale.X * engo.GetGlobalScale().X * sin
+		l.modelMatrix[3] = scale.Y * engo.GetGlobalScale().Y * -sin
+		l.modelMatrix[4] = scale.Y * engo.GetGlobalScale().Y * cos
+	} else {
+		l.modelMatrix[0] = scale.X * engo.GetGlobalScale().X
+		l.modelMatrix[1] = 0
+		l.modelMatrix[3] = 0
+		l.modelMatrix[4] = scale.Y * engo.GetGlobalScale().Y
+	}
+
+	l.modelMatrix[6] = space.Position.X * engo.GetGlobalScale().X
+	l.modelMatrix[7] = space.Position.Y * engo.GetGlobalScale().Y
+
+	engo.Gl.UniformMatrix3fv(l.matrixModel, false, l.modelMatrix)
+	setBlendMode(ren, l.uf_Premultiply)
+	engo.Gl.Uniform4f(l.uf_Color, clr[0], clr[1], clr[2], clr[3])
+	engo.Gl.Uniform2f(l.uf_Offset, caster.DX, caster.DY)
+	engo.Gl.Uniform1f(l.uf_Sigma, caster.sigma())
+
+	if edges := caster.polygonEdges(); edges != nil {
+		engo.Gl.Uniform1i(l.uf_Mode, shadowPolygon)
+		engo.Gl.Uniform1f(l.uf_Spread, caster.Spread)
+		for i := 0; i < len(edges)/4; i++ {
+			engo.Gl.Uniform4f(l.uf_Edges[i], edges[i*4], edges[i*4+1], edges[i*4+2], edges[i*4+3])
+		}
+		engo.Gl.Uniform1i(l.uf_EdgeCount, len(edges)/4)
+	} else {
+		x0, y0, x1, y1, corner := caster.box()
+		engo.Gl.Uniform1i(l.uf_Mode, shadowBox)
+		engo.Gl.Uniform4f(l.uf_Box, x0, y0, x1, y1)
+		engo.Gl.Uniform1f(l.uf_Corner, corner)
+	}
+
+	engo.Gl.DrawArrays(engo.Gl.TRIANGLE_FAN, 0, 4)
+}
+
+func (l *shadowShader) Post() {
+	l.lastBuffer = nil
+
+	// Cleanup
+	engo.Gl.DisableVertexAttribArray(l.inPosition)
+
+	engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, nil)
+
+	engo.Gl.Disable(engo.Gl.BLEND)
+}
+
+func (l *shadowShader) SetCamera(c *common.CameraSystem) {
+	if l.cameraEnabled {
+		l.camera = c
+	}
+}
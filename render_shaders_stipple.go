@@ -1,24 +1,33 @@
 package engoutil
 
 import (
+	"fmt"
+
 	"github.com/EngoEngine/ecs"
 	"github.com/EngoEngine/engo"
 	"github.com/EngoEngine/engo/common"
 	"github.com/EngoEngine/gl"
 	"github.com/EngoEngine/math"
-
-	gl2 "github.com/go-gl/gl/v2.1/gl"
 )
 
 type shapeShader struct {
 	program *gl.Program
 
 	inPosition int
+	inDistance int
 
 	matrixProjection *gl.UniformLocation
 	matrixView       *gl.UniformLocation
 	matrixModel      *gl.UniformLocation
 	uf_Color         *gl.UniformLocation
+	uf_Premultiply   *gl.UniformLocation
+	uf_Mask          *gl.UniformLocation
+	uf_Dashes        [maxDashes / 4]*gl.UniformLocation
+	uf_DashPeriod    *gl.UniformLocation
+	uf_DashOffset    *gl.UniformLocation
+
+	// the ends of the dashes and the gaps along the period
+	dashes [maxDashes]float32
 
 	projectionMatrix []float32
 	viewMatrix       []float32
@@ -34,12 +43,16 @@ func (l *shapeShader) Setup(w *ecs.World) error {
 	var err error
 	l.program, err = common.LoadShader(`
 attribute vec2 in_Position;
+attribute float in_Distance;
 
 uniform mat3 matrixProjection;
 uniform mat3 matrixView;
 uniform mat3 matrixModel;
 
+varying float var_Distance;
+
 void main() {
+  var_Distance = in_Distance;
 
   vec3 matr = matrixProjection * matrixView * matrixModel * vec3(in_Position, 1.0);
   gl_Position = vec4(matr.xy, 0, matr.z);
@@ -53,9 +66,34 @@ precision mediump float;
 #endif
 
 uniform vec4 uf_Color;
+// zero when the colors are premultiplied already
+uniform bool uf_Premultiply;
+// the mask of a group is drawn into the stencil, without its transparent pixels
+uniform bool uf_Mask;
+// the ends of the dashes and the gaps, the unused ones are beyond the period
+uniform vec4 uf_Dashes[4];
+// zero period is solid
+uniform float uf_DashPeriod;
+uniform float uf_DashOffset;
+
+varying float var_Distance;
 
 void main (void) {
+  if (uf_DashPeriod > 0.0) {
+    vec4 d = vec4(mod(var_Distance + uf_DashOffset, uf_DashPeriod));
+    // the number of ends before d, odd in the gaps
+    float k = dot(step(uf_Dashes[0], d) + step(uf_Dashes[1], d) + step(uf_Dashes[2], d) + step(uf_Dashes[3], d), vec4(1.0));
+    if (mod(k, 2.0) >= 1.0) {
+      discard;
+    }
+  }
   gl_FragColor = uf_Color;
+  if (uf_Mask && gl_FragColor.a <= 0.5) {
+    discard;
+  }
+  if (uf_Premultiply) {
+    gl_FragColor.rgb *= gl_FragColor.a;
+  }
 }`)
 
 	if err != nil {
@@ -64,12 +102,20 @@ void main (void) {
 
 	// Define things that should be read from the texture buffer
 	l.inPosition = engo.Gl.GetAttribLocation(l.program, "in_Position")
+	l.inDistance = engo.Gl.GetAttribLocation(l.program, "in_Distance")
 
 	// Define things that should be set per draw
 	l.matrixProjection = engo.Gl.GetUniformLocation(l.program, "matrixProjection")
 	l.matrixView = engo.Gl.GetUniformLocation(l.program, "matrixView")
 	l.matrixModel = engo.Gl.GetUniformLocation(l.program, "matrixModel")
 	l.uf_Color = engo.Gl.GetUniformLocation(l.program, "uf_Color")
+	l.uf_Premultiply = engo.Gl.GetUniformLocation(l.program, "uf_Premultiply")
+	l.uf_Mask = engo.Gl.GetUniformLocation(l.program, "uf_Mask")
+	for i := range l.uf_Dashes {
+		l.uf_Dashes[i] = engo.Gl.GetUniformLocation(l.program, fmt.Sprintf("uf_Dashes[%d]", i))
+	}
+	l.uf_DashPeriod = engo.Gl.GetUniformLocation(l.program, "uf_DashPeriod")
+	l.uf_DashOffset = engo.Gl.GetUniformLocation(l.program, "uf_DashOffset")
 
 	l.projectionMatrix = make([]float32, 9)
 	l.projectionMatrix[8] = 1
@@ -89,12 +135,11 @@ void main (void) {
 
 func (l *shapeShader) Pre() {
 	engo.Gl.Enable(engo.Gl.BLEND)
-	// TODO: modify in the future, engo.Gl not have LINE_STIPPLE
-	gl2.Enable(gl2.LINE_STIPPLE)
-	engo.Gl.BlendFunc(engo.Gl.SRC_ALPHA, engo.Gl.ONE_MINUS_SRC_ALPHA)
 
 	// Bind shader and buffer, enable attributes
 	engo.Gl.UseProgram(l.program)
+	resetBlendMode(l.uf_Premultiply)
+	resetMask(l.uf_Mask)
 	engo.Gl.EnableVertexAttribArray(l.inPosition)
 
 	if engo.ScaleOnResize() {
@@ -122,8 +167,8 @@ func (l *shapeShader) Pre() {
 }
 
 func (l *shapeShader) updateBuffer(ren *common.RenderComponent, space *common.SpaceComponent) {
-	if len(ren.BufferContent) == 0 {
-		ren.BufferContent = make([]float32, l.computeBufferSize(ren.Drawable)) // because we add at most this many elements to it
+	if size := l.computeBufferSize(ren.Drawable, space); len(ren.BufferContent) < size {
+		ren.BufferContent = make([]float32, size) // because we add at most this many elements to it
 	}
 
 	if changed := l.generateBufferContent(ren, space, ren.BufferContent); !changed {
@@ -137,12 +182,12 @@ func (l *shapeShader) updateBuffer(ren *common.RenderComponent, space *common.Sp
 	engo.Gl.BufferData(engo.Gl.ARRAY_BUFFER, ren.BufferContent, engo.Gl.STATIC_DRAW)
 }
 
-func (l *shapeShader) computeBufferSize(draw common.Drawable) int {
+func (l *shapeShader) computeBufferSize(draw common.Drawable, space *common.SpaceComponent) int {
 	switch shape := draw.(type) {
-	case StippleLine:
-		return len(shape.Points) * 2
-	case StippleRect:
-		return 16
+	case dashed:
+		return len(shape.dashVertices(space))
+	case tessellated:
+		return len(shape.triangles())
 	default:
 		return 0
 	}
@@ -152,40 +197,14 @@ func (l *shapeShader) generateBufferContent(ren *common.RenderComponent, space *
 	var changed bool
 
 	switch shape := ren.Drawable.(type) {
-	case StippleLine:
-		for i, v := range shape.Points {
-			setBufferValue(buffer, i*2, v.X, &changed)
-			setBufferValue(buffer, i*2+1, v.Y, &changed)
+	case dashed:
+		for i, v := range shape.dashVertices(space) {
+			setBufferValue(buffer, i, v, &changed)
+		}
+	case tessellated:
+		for i, v := range shape.triangles() {
+			setBufferValue(buffer, i, v, &changed)
 		}
-	case StippleRect:
-		w, h := space.Width, space.Height
-		// top
-		// setBufferValue(buffer, 0, 0, &changed)
-		// setBufferValue(buffer, 1, 0, &changed)
-
-		setBufferValue(buffer, 2, w, &changed)
-		// setBufferValue(buffer, 3, 0, &changed)
-
-		// right
-		setBufferValue(buffer, 4, w, &changed)
-		// setBufferValue(buffer, 5, 0, &changed)
-
-		setBufferValue(buffer, 6, w, &changed)
-		setBufferValue(buffer, 7, h, &changed)
-
-		// bottom
-		setBufferValue(buffer, 8, w, &changed)
-		setBufferValue(buffer, 9, h, &changed)
-
-		// setBufferValue(buffer, 10, 0, &changed)
-		setBufferValue(buffer, 11, h, &changed)
-
-		// left
-		// setBufferValue(buffer, 12, 0, &changed)
-		setBufferValue(buffer, 13, h, &changed)
-
-		// setBufferValue(buffer, 14, 0, &changed)
-		// setBufferValue(buffer, 15, 0, &changed)
 	}
 
 	return changed
@@ -196,7 +215,15 @@ func (l *shapeShader) Draw(ren *common.RenderComponent, space *common.SpaceCompo
 		l.updateBuffer(ren, space)
 
 		engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, ren.Buffer)
-		engo.Gl.VertexAttribPointer(l.inPosition, 2, engo.Gl.FLOAT, false, 8, 0)
+		switch ren.Drawable.(type) {
+		case dashed:
+			engo.Gl.EnableVertexAttribArray(l.inDistance)
+			engo.Gl.VertexAttribPointer(l.inPosition, 2, engo.Gl.FLOAT, false, 12, 0)
+			engo.Gl.VertexAttribPointer(l.inDistance, 1, engo.Gl.FLOAT, false, 12, 8)
+		default:
+			engo.Gl.DisableVertexAttribArray(l.inDistance)
+			engo.Gl.VertexAttribPointer(l.inPosition, 2, engo.Gl.FLOAT, false, 8, 0)
+		}
 
 		l.lastBuffer = ren.Buffer
 	}
@@ -224,21 +251,62 @@ func (l *shapeShader) Draw(ren *common.RenderComponent, space *common.SpaceCompo
 	}
 
 	engo.Gl.UniformMatrix3fv(l.matrixModel, false, l.modelMatrix)
+	setBlendMode(ren, l.uf_Premultiply)
 	color := ParseColor(ren.Color).Vec4()
 	engo.Gl.Uniform4f(l.uf_Color, color[0], color[1], color[2], color[3])
 
 	switch shape := ren.Drawable.(type) {
-	case StippleLine:
-		// TODO: modify in the future, engo.Gl not have LineStipple
-		gl2.LineStipple(shape.Stipple.Factor, shape.Stipple.Pattern)
-		engo.Gl.LineWidth(shape.BorderWidth)
-		engo.Gl.DrawArrays(engo.Gl.LINES, 0, len(shape.Points)*2)
-	case StippleRect:
-		// TODO: modify in the future, engo.Gl not have LineStipple
-		gl2.LineStipple(shape.Stipple.Factor, shape.Stipple.Pattern)
-		engo.Gl.LineWidth(shape.BorderWidth)
-		engo.Gl.DrawArrays(engo.Gl.LINES, 0, 16)
+	case *PathMesh:
+		// fill, then stroke over it
+		if color[3] > 0 && shape.fill > 0 {
+			engo.Gl.DrawArrays(engo.Gl.TRIANGLES, 0, shape.fill/2)
+		}
+		if stroke := shape.StrokeColor.Vec4(); stroke[3] > 0 && len(shape.vertices) > shape.fill {
+			engo.Gl.Uniform4f(l.uf_Color, stroke[0], stroke[1], stroke[2], stroke[3])
+			engo.Gl.DrawArrays(engo.Gl.TRIANGLES, shape.fill/2, (len(shape.vertices)-shape.fill)/2)
+		}
+	case *Outline:
+		// fill, then the dashed stroke over it
+		if color[3] > 0 && shape.fill > 0 {
+			engo.Gl.DrawArrays(engo.Gl.TRIANGLES, 0, shape.fill/3)
+		}
+		stroke := color
+		if shape.StrokeColor != nil {
+			stroke = shape.StrokeColor.Vec4()
+		}
+		if stroke[3] > 0 && len(shape.vertices) > shape.fill {
+			engo.Gl.Uniform4f(l.uf_Color, stroke[0], stroke[1], stroke[2], stroke[3])
+			l.setDashes(shape.Stipple)
+			engo.Gl.DrawArrays(engo.Gl.TRIANGLES, shape.fill/3, (len(shape.vertices)-shape.fill)/3)
+			l.setDashes(Stipple{Pattern: 0xFFFF})
+		}
+	case dashed:
+		l.setDashes(shape.stipple())
+		engo.Gl.DrawArrays(engo.Gl.TRIANGLES, 0, len(shape.dashVertices(space))/3)
+		l.setDashes(Stipple{Pattern: 0xFFFF})
+	case tessellated:
+		engo.Gl.DrawArrays(engo.Gl.TRIANGLES, 0, len(shape.triangles())/2)
+	}
+}
+
+// setDashes sets the pattern of the next draw
+func (l *shapeShader) setDashes(stipple Stipple) {
+	runs, offset := stipple.runs()
+	var period float32
+	for i := range l.dashes {
+		if i < len(runs) {
+			period += runs[i]
+			l.dashes[i] = period
+		} else {
+			// never reached
+			l.dashes[i] = math.MaxFloat32
+		}
+	}
+	for i := range l.uf_Dashes {
+		engo.Gl.Uniform4f(l.uf_Dashes[i], l.dashes[i*4], l.dashes[i*4+1], l.dashes[i*4+2], l.dashes[i*4+3])
 	}
+	engo.Gl.Uniform1f(l.uf_DashPeriod, period)
+	engo.Gl.Uniform1f(l.uf_DashOffset, offset)
 }
 
 func (l *shapeShader) Post() {
@@ -246,12 +314,11 @@ func (l *shapeShader) Post() {
 
 	// Cleanup
 	engo.Gl.DisableVertexAttribArray(l.inPosition)
+	engo.Gl.DisableVertexAttribArray(l.inDistance)
 
 	engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, nil)
 
 	engo.Gl.Disable(engo.Gl.BLEND)
-	// TODO: modify in the future, engo.Gl not have LINE_STIPPLE
-	engo.Gl.Disable(gl2.LINE_STIPPLE)
 }
 
 func (l *shapeShader) SetCamera(c *common.CameraSystem) {
@@ -1,6 +1,8 @@
 package engoutil
 
 import (
+	"strconv"
+
 	"github.com/EngoEngine/ecs"
 	"github.com/EngoEngine/engo"
 	"github.com/EngoEngine/engo/common"
@@ -21,8 +23,15 @@ type textShader struct {
 	matrixView       *gl.UniformLocation
 	matrixModel      *gl.UniformLocation
 	uf_Color         *gl.UniformLocation
+	uf_Premultiply   *gl.UniformLocation
+	uf_Mask          *gl.UniformLocation
 	uf_Target        *gl.UniformLocation
 	uf_TexSize       *gl.UniformLocation
+	uf_Blur          *gl.UniformLocation
+	uf_Spread        *gl.UniformLocation
+	uf_Radius        *gl.UniformLocation
+	uf_Distance      *gl.UniformLocation
+	uf_Smoothing     *gl.UniformLocation
 
 	projectionMatrix []float32
 	viewMatrix       []float32
@@ -36,6 +45,8 @@ type textShader struct {
 }
 
 func (l *textShader) Setup(*ecs.World) error {
+	// fwidth of the SDF atlases, WebGL enables the extension before the shader is compiled
+	enableDerivatives()
 	var err error
 	l.program, err = common.LoadShader(`
 attribute vec2 in_Position;
@@ -54,10 +65,16 @@ void main() {
   gl_Position = vec4(matr.xy, 0, matr.z);
 }
 `, `
+#define OUTLINE_RADIUS `+strconv.Itoa(textOutlineMaxRadius)+`
 #ifdef GL_ES
+#ifdef GL_OES_standard_derivatives
+#extension GL_OES_standard_derivatives : enable
+#define DERIVATIVES
+#endif
 #define LOWP lowp
 precision mediump float;
 #else
+#define DERIVATIVES
 #define LOWP
 #endif
 
@@ -65,22 +82,78 @@ varying vec2 var_TexCoords;
 
 uniform sampler2D uf_Texture;
 uniform vec4 uf_Color;
+// zero when the colors are premultiplied already
+uniform bool uf_Premultiply;
+// the mask of a group is drawn into the stencil, without its transparent pixels
+uniform bool uf_Mask;
 uniform int uf_Target;
 uniform vec2 uf_TexSize;
+// the radius of the shadow blur, in texture coordinates
+uniform vec2 uf_Blur;
+uniform float uf_Spread;
+// the width of the outline, in pixels of the font texture. the blur of the shadow of the SDF atlases
+uniform float uf_Radius;
+// the distances of the SDF atlases, in pixels of the texture. zero for the bitmap atlases
+uniform float uf_Distance;
+// the pixels of the texture in a pixel of the text, the width of the edges without the derivatives
+uniform float uf_Smoothing;
 
 void main (void) {
-  if (uf_Target == 1) {
+  if (uf_Target != 1 && uf_Distance > 0.0) {
+    // the signed distance to the edge, and its change in a pixel of the screen
+    float d = (texture2D(uf_Texture, var_TexCoords).a - 0.5) * 2.0 * uf_Distance;
+#ifdef DERIVATIVES
+    float w = max(fwidth(d), 0.0001);
+#else
+    float w = clamp(uf_Smoothing, 0.0001, uf_Distance);
+#endif
+    float alpha;
+    if (uf_Target == 2) {
+      alpha = clamp((d + uf_Radius) / w + 0.5, 0.0, 1.0);
+    } else if (uf_Target == 3) {
+      float r = max(uf_Radius, w);
+      alpha = min(smoothstep(-r, r, d) * uf_Spread, 1.0);
+    } else {
+      alpha = clamp(d / w + 0.5, 0.0, 1.0);
+    }
+    gl_FragColor = vec4(uf_Color.rgb, uf_Color.a * alpha);
+  } else if (uf_Target == 1) {
     gl_FragColor = uf_Color;
   } else if (uf_Target == 2) {
-	float alpha = 4.0*texture2D(uf_Texture, var_TexCoords).a;
-	alpha -= texture2D(uf_Texture, var_TexCoords + vec2(uf_TexSize.x, 0)).a;
-	alpha -= texture2D(uf_Texture, var_TexCoords + vec2(-uf_TexSize.x, 0)).a;
-	alpha -= texture2D(uf_Texture, var_TexCoords + vec2(0, uf_TexSize.y)).a;
-    alpha -= texture2D(uf_Texture, var_TexCoords + vec2(0, -uf_TexSize.y)).a;
-    gl_FragColor = vec4(uf_Color.xyz, alpha);
+    // the outline, the characters dilated by uf_Radius texels, antialiased at the edge
+    float alpha = 0.0;
+    for (int i = -OUTLINE_RADIUS; i <= OUTLINE_RADIUS; i++) {
+      for (int j = -OUTLINE_RADIUS; j <= OUTLINE_RADIUS; j++) {
+        vec2 o = vec2(float(i), float(j));
+        float coverage = clamp(uf_Radius + 0.5 - length(o), 0.0, 1.0);
+        if (coverage > 0.0) {
+          alpha = max(alpha, coverage * texture2D(uf_Texture, var_TexCoords + o * uf_TexSize).a);
+        }
+      }
+    }
+    gl_FragColor = vec4(uf_Color.rgb, uf_Color.a * alpha);
+  } else if (uf_Target == 3) {
+    // the shadow, a Gaussian blur sampled on 7x7 points within 3 sigma
+    float alpha = 0.0;
+    float total = 0.0;
+    for (int i = -3; i <= 3; i++) {
+      for (int j = -3; j <= 3; j++) {
+        vec2 o = vec2(float(i), float(j)) / 3.0;
+        float weight = exp(-4.5 * dot(o, o));
+        alpha += weight * texture2D(uf_Texture, var_TexCoords + o * uf_Blur).a;
+        total += weight;
+      }
+    }
+    gl_FragColor = vec4(uf_Color.rgb, uf_Color.a * min(alpha / total * uf_Spread, 1.0));
   } else {
 	gl_FragColor = uf_Color * texture2D(uf_Texture, var_TexCoords);
   }
+  if (uf_Mask && gl_FragColor.a <= 0.5) {
+    discard;
+  }
+  if (uf_Premultiply) {
+    gl_FragColor.rgb *= gl_FragColor.a;
+  }
 }`)
 
 	if err != nil {
@@ -110,8 +183,15 @@ void main (void) {
 	l.matrixView = engo.Gl.GetUniformLocation(l.program, "matrixView")
 	l.matrixModel = engo.Gl.GetUniformLocation(l.program, "matrixModel")
 	l.uf_Color = engo.Gl.GetUniformLocation(l.program, "uf_Color")
+	l.uf_Premultiply = engo.Gl.GetUniformLocation(l.program, "uf_Premultiply")
+	l.uf_Mask = engo.Gl.GetUniformLocation(l.program, "uf_Mask")
 	l.uf_Target = engo.Gl.GetUniformLocation(l.program, "uf_Target")
 	l.uf_TexSize = engo.Gl.GetUniformLocation(l.program, "uf_TexSize")
+	l.uf_Blur = engo.Gl.GetUniformLocation(l.program, "uf_Blur")
+	l.uf_Spread = engo.Gl.GetUniformLocation(l.program, "uf_Spread")
+	l.uf_Radius = engo.Gl.GetUniformLocation(l.program, "uf_Radius")
+	l.uf_Distance = engo.Gl.GetUniformLocation(l.program, "uf_Distance")
+	l.uf_Smoothing = engo.Gl.GetUniformLocation(l.program, "uf_Smoothing")
 
 	l.projectionMatrix = make([]float32, 9)
 	l.projectionMatrix[8] = 1
@@ -131,10 +211,11 @@ void main (void) {
 
 func (l *textShader) Pre() {
 	engo.Gl.Enable(engo.Gl.BLEND)
-	engo.Gl.BlendFunc(engo.Gl.SRC_ALPHA, engo.Gl.ONE_MINUS_SRC_ALPHA)
 
 	// Bind shader and buffer, enable attributes
 	engo.Gl.UseProgram(l.program)
+	resetBlendMode(l.uf_Premultiply)
+	resetMask(l.uf_Mask)
 	engo.Gl.BindBuffer(engo.Gl.ELEMENT_ARRAY_BUFFER, l.indicesRectanglesVBO)
 	engo.Gl.EnableVertexAttribArray(l.inPosition)
 	engo.Gl.EnableVertexAttribArray(l.inTexCoords)
@@ -170,11 +251,17 @@ func (l *textShader) updateBuffer(ren *common.RenderComponent, space *common.Spa
 		return
 	}
 
-	if len(ren.BufferContent) < 16*txt.Length()+16 {
-		ren.BufferContent = make([]float32, 16*txt.Length()+16)
+	var layout *textLayout
+	if txt.changed() {
+		// the buffer is sized for the quads of the layout
+		lay := txt.layout()
+		layout, txt.buffered.quads = &lay, lay.quads()
+	}
+	if size := textBufferSize(txt); len(ren.BufferContent) < size {
+		ren.BufferContent = make([]float32, size)
 	}
 
-	if changed := l.generateBufferContent(ren, space, ren.BufferContent); !changed {
+	if changed := l.generateBufferContent(ren, space, ren.BufferContent, layout); !changed {
 		return
 	}
 
@@ -185,7 +272,94 @@ func (l *textShader) updateBuffer(ren *common.RenderComponent, space *common.Spa
 	engo.Gl.BufferData(engo.Gl.ARRAY_BUFFER, ren.BufferContent, engo.Gl.STATIC_DRAW)
 }
 
-func (l *textShader) generateBufferContent(ren *common.RenderComponent, space *common.SpaceComponent, buffer []float32) (changed bool) {
+// textBufferSize the background, the characters, and their shadow and outline after them
+func textBufferSize(txt *Text) int {
+	_, _, total := textQuadBases(txt)
+	return 16 * total
+}
+
+// textQuadCapacity the quads of the glyphs, the background, the shadow and the outline quads are within bufferSize
+func textQuadCapacity(txt *Text) int {
+	layers := 1
+	if txt.Shadow != nil {
+		layers++
+	}
+	if txt.OutlineWidth > 0 {
+		layers++
+	}
+	if capacity := txt.buffered.quads; capacity < (bufferSize-1)/layers {
+		return capacity
+	}
+	return (bufferSize - 1) / layers
+}
+
+// textQuadBases the first quads of the shadow and of the outline, the quad of the glyph i is at base+i-1.
+// zero without them
+func textQuadBases(txt *Text) (shadow, outline, total int) {
+	capacity := textQuadCapacity(txt)
+	total = 1 + capacity
+	if txt.Shadow != nil {
+		shadow = total
+		total += capacity
+	}
+	if txt.OutlineWidth > 0 {
+		outline = total
+		total += capacity
+	}
+	return
+}
+
+// growQuads copies the quads of the runs to base, grown by r, with the offset
+func growQuads(buffer []float32, runs []textRun, base int, r, dx, dy float32, solid bool, changed *bool) {
+	grow := [4][2]float32{{-r, -r}, {r, -r}, {r, r}, {-r, r}}
+	for _, run := range runs {
+		var du, dv float32
+		if run.page != nil {
+			du, dv = r/run.ratio/run.page.Width, r/run.ratio/run.page.Height
+		} else if !solid {
+			continue
+		}
+		for i := run.first; i < run.first+run.count; i++ {
+			var quad [4]engo.Point
+			for k := range quad {
+				quad[k] = engo.Point{X: buffer[i*16+k*4] + grow[k][0] + dx, Y: buffer[i*16+k*4+1] + grow[k][1] + dy}
+			}
+			u0, v0, u1, v1 := buffer[i*16+2]-du, buffer[i*16+3]-dv, buffer[i*16+10]+du, buffer[i*16+11]+dv
+			setTextQuad(buffer, base+i-1, quad, u0, v0, u1, v1, changed)
+		}
+	}
+}
+
+// textRun the quads drawn together, the characters of a page of an atlas in a color, or the underlines
+type textRun struct {
+	// nil for the solid quads of the underlines
+	atlas *FontAtlas
+	page  *AtlasPage
+	// the generation of the page when the texture coordinates were buffered
+	generation int
+	// the glyphs of the run in the atlas, they are not evicted while they are drawn
+	slots []*glyphSlot
+	// the size of a pixel of the atlas, see Font.ratio
+	ratio float32
+	// nil is the color of the text
+	color        *Color
+	first, count int
+}
+
+// setTextQuad the corners from the top left, clockwise
+func setTextQuad(buffer []float32, index int, quad [4]engo.Point, u0, v0, u1, v1 float32, changed *bool) {
+	index *= 16
+	uv := [4][2]float32{{u0, v0}, {u1, v0}, {u1, v1}, {u0, v1}}
+	for i, p := range quad {
+		setBufferValue(buffer, index+i*4, p.X, changed)
+		setBufferValue(buffer, index+i*4+1, p.Y, changed)
+		setBufferValue(buffer, index+i*4+2, uv[i][0], changed)
+		setBufferValue(buffer, index+i*4+3, uv[i][1], changed)
+	}
+}
+
+// generateBufferContent the layout is nil when the text has not changed
+func (l *textShader) generateBufferContent(ren *common.RenderComponent, space *common.SpaceComponent, buffer []float32, layout *textLayout) (changed bool) {
 	txt, ok := ren.Drawable.(*Text)
 	if !ok {
 		unsupportedType(ren.Drawable)
@@ -193,88 +367,144 @@ func (l *textShader) generateBufferContent(ren *common.RenderComponent, space *c
 	}
 
 	var (
-		size               [2]float32
-		atlas              = txt.Font.updateFontAtlas(txt.Text)
-		currentX, currentY float32
-		count              = 1
-		index              int
-		w, h, x, y         float32
-		offsetX, offsetY   float32
+		size             [2]float32
+		count            = 1
+		w, h, x, y       float32
+		offsetX, offsetY float32
 	)
 
-	if txt.changed() {
+	if layout != nil {
 		for i := 0; i < len(buffer); i++ {
 			buffer[i] = 0
 		}
-		for _, char := range []rune(txt.Text) {
-			// skip invisible characters
-			if _, ok := atlas.Width[char]; !ok {
+		var runs []textRun
+		// the quads are batched by their pages and colors, the glyphs of the fonts are mixed in the lines
+		type batch struct {
+			run   textRun
+			quads [][4]engo.Point
+			uvs   [][4]float32
+		}
+		var batches []*batch
+		add := func(atlas *FontAtlas, page *AtlasPage, slot *glyphSlot, ratio float32, clr *Color, quad [4]engo.Point, u0, v0, u1, v1 float32) {
+			var b *batch
+			for _, candidate := range batches {
+				if candidate.run.page == page && candidate.run.ratio == ratio && candidate.run.color == clr {
+					b = candidate
+					break
+				}
+			}
+			if b == nil {
+				b = &batch{run: textRun{atlas: atlas, page: page, ratio: ratio, color: clr}}
+				if page != nil {
+					b.run.generation = page.generation
+				}
+				batches = append(batches, b)
+			}
+			if slot != nil {
+				b.run.slots = append(b.run.slots, slot)
+			}
+			b.quads = append(b.quads, quad)
+			b.uvs = append(b.uvs, [4]float32{u0, v0, u1, v1})
+		}
+
+		txt.buffered.missing = false
+		for _, g := range layout.glyphs {
+			style, atlas := g.style, g.style.atlas
+			index, ok := atlas.Page[g.index]
+			if !ok {
+				// the page was full, it is laid out again
+				txt.buffered.missing = true
 				continue
 			}
-			if char == '\n' {
-				currentX = 0
-				currentY += atlas.LineHeight + txt.LineSpacing
-				size[1] += atlas.LineHeight + txt.LineSpacing
+			page := atlas.Pages[index]
+			w, h = atlas.Width[g.index], atlas.Height[g.index]
+			x, y = atlas.XLocation[g.index], atlas.YLocation[g.index]
+			offsetX = g.x + atlas.LeftSide[g.index]*style.ratio
+			offsetY = g.y + atlas.OffsetY[g.index]*style.ratio
+
+			// the quad is the size of the glyph in the text, the texture coordinates of it in the atlas
+			quad := [4]engo.Point{{X: offsetX, Y: offsetY}, {X: offsetX + w*style.ratio, Y: offsetY},
+				{X: offsetX + w*style.ratio, Y: offsetY + h*style.ratio}, {X: offsetX, Y: offsetY + h*style.ratio}}
+			if style.italic {
+				// slanted around the baseline
+				baseline := g.y + style.ascent()
+				for i := range quad {
+					quad[i].X += (baseline - quad[i].Y) * italicSkew
+				}
+			}
+			u0, v0 := x/page.Width, y/page.Height
+			u1, v1 := (x+w)/page.Width, (y+h)/page.Height
+			add(atlas, page, atlas.slots[g.index], style.ratio, style.color, quad, u0, v0, u1, v1)
+			if style.bold {
+				offset := style.boldOffset()
+				for i := range quad {
+					quad[i].X += offset
+				}
+				add(atlas, page, nil, style.ratio, style.color, quad, u0, v0, u1, v1)
+			}
+		}
+		for _, u := range layout.underlines {
+			offset, thickness := u.style.underlineRect()
+			x0, x1 := u.x, u.x+u.advance
+			y0 := u.y + u.style.ascent() + offset
+			add(nil, nil, nil, 1, u.style.color, [4]engo.Point{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y0 + thickness}, {X: x0, Y: y0 + thickness}}, 0, 0, 0, 0)
+		}
+		capacity := textQuadCapacity(txt)
+		if n := txt.buffered.quads; capacity < n {
+			warning("Text, %d quads exceeds the limit of %d, the rest are not drawn", n, capacity)
+		}
+		for _, b := range batches {
+			// the quads beyond the index buffer are not drawn
+			if room := capacity + 1 - count; len(b.quads) > room {
+				b.quads = b.quads[:room]
+			}
+			if len(b.quads) == 0 {
 				continue
 			}
-			index = count * 16
-			w, h = atlas.Width[char], atlas.Height[char]
-			x, y = atlas.XLocation[char], atlas.YLocation[char]
-			offsetX = atlas.LeftSide[char]
-			offsetY = atlas.OffsetY[char]
-
-			// These five are at 0, 0:
-			setBufferValue(buffer, 0+index, currentX+offsetX, &changed)
-			setBufferValue(buffer, 1+index, currentY+offsetY, &changed)
-			setBufferValue(buffer, 2+index, x/atlas.TotalWidth, &changed)
-			setBufferValue(buffer, 3+index, y/atlas.TotalHeight, &changed)
-
-			// These five are at 1, 0:
-			setBufferValue(buffer, 4+index, currentX+offsetX+w, &changed)
-			setBufferValue(buffer, 5+index, currentY+offsetY, &changed)
-			setBufferValue(buffer, 6+index, (x+w)/atlas.TotalWidth, &changed)
-			setBufferValue(buffer, 7+index, y/atlas.TotalHeight, &changed)
-
-			// These five are at 1, 1:
-			setBufferValue(buffer, 8+index, currentX+offsetX+w, &changed)
-			setBufferValue(buffer, 9+index, currentY+h+offsetY, &changed)
-			setBufferValue(buffer, 10+index, (x+w)/atlas.TotalWidth, &changed)
-			setBufferValue(buffer, 11+index, (y+h)/atlas.TotalHeight, &changed)
-
-			// These five are at 0, 1:
-			setBufferValue(buffer, 12+index, currentX+offsetX, &changed)
-			setBufferValue(buffer, 13+index, currentY+h+offsetY, &changed)
-			setBufferValue(buffer, 14+index, x/atlas.TotalWidth, &changed)
-			setBufferValue(buffer, 15+index, (y+h)/atlas.TotalHeight, &changed)
-
-			currentX += offsetX + w + atlas.RightSide[char] + txt.LetterSpacing
-			if size[0] < currentX {
-				size[0] = currentX
+			b.run.first, b.run.count = count, len(b.quads)
+			for i, quad := range b.quads {
+				uv := b.uvs[i]
+				setTextQuad(buffer, count, quad, uv[0], uv[1], uv[2], uv[3], &changed)
+				count++
 			}
-			count++
+			runs = append(runs, b.run)
 		}
-		size[1] = currentY + atlas.LineHeight
+		size = [2]float32{layout.width, layout.height}
+
+		shadowBase, outlineBase, _ := textQuadBases(txt)
+		if txt.Shadow != nil {
+			// the characters grown by the blur radius, with the offset
+			shadow := txt.shadowQuad()
+			growQuads(buffer, runs, shadowBase, shadow[2], shadow[0], shadow[1], false, &changed)
+			txt.buffered.shadow = shadow
+		}
+		if outline := txt.outlineRadius(); outline > 0 {
+			// the underlines are outlined too
+			growQuads(buffer, runs, outlineBase, outline, 0, 0, true, &changed)
+		}
+		txt.buffered.outline = txt.outlineRadius()
 		txt.size = size
+		txt.buffered.runs = runs
 		txt.buffered.text = txt.Text
 		txt.buffered.lineSpacing = txt.LineSpacing
 		txt.buffered.letterSpacing = txt.LetterSpacing
+		txt.buffered.kerning = txt.Kerning
+		txt.buffered.width, txt.buffered.height = txt.width, txt.height
+		txt.buffered.align, txt.buffered.valign = txt.Align, txt.VAlign
+		txt.buffered.fallbacks = append(txt.buffered.fallbacks[:0], txt.Fallbacks...)
+		txt.buffered.spans = nil
+		if txt.Spans != nil {
+			txt.buffered.spans = append(make([]Span, 0, len(txt.Spans)), txt.Spans...)
+		}
 	} else {
 		size = txt.size
 	}
 
+	// the box of the layout, with the padding
 	w, h = size[0], size[1]
-	if txt.width != 0 && txt.height != 0 {
-		w, h = txt.width*txt.Font.scale, txt.height*txt.Font.scale
-	}
-	x, y = 0, 0
-	x = (size[0] - w) / 2
-	y = (size[1] - h) / 2
-	y -= txt.Padding.Top
-	h += txt.Padding.Top
-	w += txt.Padding.Right
-	h += txt.Padding.Bottom
-	x -= txt.Padding.Left
-	w += txt.Padding.Left
+	x, y = -txt.Padding.Left, -txt.Padding.Top
+	w += txt.Padding.Left + txt.Padding.Right
+	h += txt.Padding.Top + txt.Padding.Bottom
 
 	// background rectangle
 	setBufferValue(buffer, 0, x, &changed)
@@ -297,11 +527,17 @@ func (l *textShader) generateBufferContent(ren *common.RenderComponent, space *c
 	// setBufferValue(buffer, 14, 0, &changed)
 	// setBufferValue(buffer, 15, 0, &changed)
 
-	// for MouseComponent working
-	space.Position.X = txt.Position.X + x
-	space.Position.Y = txt.Position.Y + y
-	space.Width = w * ren.Scale.X
-	space.Height = h * ren.Scale.X
+	// for MouseComponent working, the shadow is part of the text
+	x0, y0, x1, y1 := x, y, x+w, y+h
+	if txt.Shadow != nil && txt.Shadow.Color != nil {
+		dx, dy, r := txt.buffered.shadow[0], txt.buffered.shadow[1], txt.buffered.shadow[2]
+		x0, y0 = math.Min(x0, dx-r), math.Min(y0, dy-r)
+		x1, y1 = math.Max(x1, size[0]+dx+r), math.Max(y1, size[1]+dy+r)
+	}
+	space.Position.X = txt.Position.X + x0*ren.Scale.X
+	space.Position.Y = txt.Position.Y + y0*ren.Scale.Y
+	space.Width = (x1 - x0) * ren.Scale.X
+	space.Height = (y1 - y0) * ren.Scale.Y
 
 	return
 }
@@ -314,6 +550,8 @@ func (l *textShader) Draw(ren *common.RenderComponent, space *common.SpaceCompon
 
 	if l.lastBuffer != ren.Buffer || ren.Buffer == nil {
 		l.updateBuffer(ren, space)
+		// the layout uploads the pages of the atlases, their textures are bound
+		l.lastTexture = nil
 
 		engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, ren.Buffer)
 		engo.Gl.VertexAttribPointer(l.inPosition, 2, engo.Gl.FLOAT, false, 16, 0)
@@ -321,16 +559,6 @@ func (l *textShader) Draw(ren *common.RenderComponent, space *common.SpaceCompon
 		l.lastBuffer = ren.Buffer
 	}
 
-	atlas := txt.Font.updateFontAtlas(txt.Text)
-
-	if atlas.Texture != l.lastTexture {
-		engo.Gl.BindTexture(engo.Gl.TEXTURE_2D, atlas.Texture)
-		l.lastTexture = atlas.Texture
-	}
-
-	engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_WRAP_S, engo.Gl.CLAMP_TO_EDGE)
-	engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_WRAP_T, engo.Gl.CLAMP_TO_EDGE)
-
 	if space.Rotation != 0 {
 		sin, cos := math.Sincos(space.Rotation * math.Pi / 180)
 
@@ -349,6 +577,9 @@ func (l *textShader) Draw(ren *common.RenderComponent, space *common.SpaceCompon
 	l.modelMatrix[7] = txt.Position.Y * engo.GetGlobalScale().Y
 
 	engo.Gl.UniformMatrix3fv(l.matrixModel, false, l.modelMatrix)
+	setBlendMode(ren, l.uf_Premultiply)
+
+	runs := txt.buffered.runs
 
 	// draw background
 	if _, _, _, alpha := ren.Color.RGBA(); alpha > 0 {
@@ -356,22 +587,95 @@ func (l *textShader) Draw(ren *common.RenderComponent, space *common.SpaceCompon
 		engo.Gl.Uniform1i(l.uf_Target, 1)
 		engo.Gl.Uniform4f(l.uf_Color, bg[0], bg[1], bg[2], bg[3])
 		if txt.BgStyle == BG_FILL_WRAP {
-			engo.Gl.DrawElements(engo.Gl.TRIANGLES, 6*txt.Length(), engo.Gl.UNSIGNED_SHORT, 12)
+			for _, run := range runs {
+				if run.atlas != nil {
+					engo.Gl.DrawElements(engo.Gl.TRIANGLES, 6*run.count, engo.Gl.UNSIGNED_SHORT, run.first*12)
+				}
+			}
 		} else {
 			engo.Gl.DrawElements(engo.Gl.TRIANGLES, 6, engo.Gl.UNSIGNED_SHORT, 0)
 		}
 	}
 
-	// TODO: draw text outline
-	// engo.Gl.Uniform1i(l.uf_Target, 2)
-	// engo.Gl.Uniform2f(l.uf_TexSize, 1/txt.size[0], 1/txt.size[1])
-	// engo.Gl.DrawElements(engo.Gl.TRIANGLES, 6*txt.Length(), engo.Gl.UNSIGNED_SHORT, 12)
+	// draw shadow, when the buffer has it
+	if shadow := txt.Shadow; shadow != nil && shadow.Color != nil && txt.buffered.shadow == txt.shadowQuad() &&
+		len(ren.BufferContent) >= textBufferSize(txt) {
+		clr := shadow.Color.Vec4()
+		radius := txt.buffered.shadow[2]
+		base, _, _ := textQuadBases(txt)
+		engo.Gl.Uniform1i(l.uf_Target, 3)
+		engo.Gl.Uniform4f(l.uf_Color, clr[0], clr[1], clr[2], clr[3])
+		engo.Gl.Uniform1f(l.uf_Spread, 1+math.Max(shadow.Spread, 0))
+		for _, run := range runs {
+			if run.atlas == nil {
+				continue
+			}
+			l.bindTexture(run.page.Texture)
+			l.setDistance(run)
+			engo.Gl.Uniform1f(l.uf_Radius, radius/run.ratio)
+			engo.Gl.Uniform2f(l.uf_Blur, radius/run.page.Width, radius/run.page.Height)
+			engo.Gl.DrawElements(engo.Gl.TRIANGLES, 6*run.count, engo.Gl.UNSIGNED_SHORT, (base+run.first-1)*12)
+		}
+	}
+
+	// draw outline, behind the characters
+	if radius := txt.buffered.outline; radius > 0 && txt.OutlineColor != nil && radius == txt.outlineRadius() &&
+		len(ren.BufferContent) >= textBufferSize(txt) {
+		clr := txt.OutlineColor.Vec4()
+		_, base, _ := textQuadBases(txt)
+		engo.Gl.Uniform4f(l.uf_Color, clr[0], clr[1], clr[2], clr[3])
+		for _, run := range runs {
+			if run.atlas == nil {
+				engo.Gl.Uniform1i(l.uf_Target, 1)
+			} else {
+				l.bindTexture(run.page.Texture)
+				engo.Gl.Uniform1i(l.uf_Target, 2)
+				l.setDistance(run)
+				engo.Gl.Uniform1f(l.uf_Radius, radius/run.ratio)
+				engo.Gl.Uniform2f(l.uf_TexSize, 1/run.page.Width, 1/run.page.Height)
+			}
+			engo.Gl.DrawElements(engo.Gl.TRIANGLES, 6*run.count, engo.Gl.UNSIGNED_SHORT, (base+run.first-1)*12)
+		}
+	}
 
-	// draw text
-	fg := txt.Color.Vec4()
-	engo.Gl.Uniform1i(l.uf_Target, 0)
-	engo.Gl.Uniform4f(l.uf_Color, fg[0], fg[1], fg[2], fg[3])
-	engo.Gl.DrawElements(engo.Gl.TRIANGLES, 6*txt.Length(), engo.Gl.UNSIGNED_SHORT, 12)
+	// draw text, the runs of the spans in their colors
+	for _, run := range runs {
+		clr := txt.Color
+		if run.color != nil {
+			clr = run.color
+		}
+		if run.atlas == nil {
+			engo.Gl.Uniform1i(l.uf_Target, 1)
+		} else {
+			l.bindTexture(run.page.Texture)
+			engo.Gl.Uniform1i(l.uf_Target, 0)
+			l.setDistance(run)
+			// the page and the glyphs are not evicted while they are drawn
+			run.page.lastUsed = fontFrame
+			for _, slot := range run.slots {
+				slot.lastUsed = fontFrame
+			}
+		}
+		fg := clr.Vec4()
+		engo.Gl.Uniform4f(l.uf_Color, fg[0], fg[1], fg[2], fg[3])
+		engo.Gl.DrawElements(engo.Gl.TRIANGLES, 6*run.count, engo.Gl.UNSIGNED_SHORT, run.first*12)
+	}
+}
+
+// setDistance the distances of the atlas of the run, the scale of the text is unknown without the derivatives
+func (l *textShader) setDistance(run textRun) {
+	engo.Gl.Uniform1f(l.uf_Distance, run.atlas.Distance)
+	engo.Gl.Uniform1f(l.uf_Smoothing, 1/run.ratio)
+}
+
+func (l *textShader) bindTexture(texture *gl.Texture) {
+	if texture == l.lastTexture {
+		return
+	}
+	engo.Gl.BindTexture(engo.Gl.TEXTURE_2D, texture)
+	engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_WRAP_S, engo.Gl.CLAMP_TO_EDGE)
+	engo.Gl.TexParameteri(engo.Gl.TEXTURE_2D, engo.Gl.TEXTURE_WRAP_T, engo.Gl.CLAMP_TO_EDGE)
+	l.lastTexture = texture
 }
 
 func (l *textShader) Post() {
@@ -0,0 +1,11 @@
+//go:build (darwin || linux || windows) && !ios && !android && !js && !nogl
+// +build darwin linux windows
+// +build !ios
+// +build !android
+// +build !js
+// +build !nogl
+
+package engoutil
+
+// enableDerivatives fwidth is in the core of OpenGL 2
+func enableDerivatives() {}
@@ -0,0 +1,12 @@
+//go:build js && !nogl
+// +build js,!nogl
+
+package engoutil
+
+import "github.com/EngoEngine/engo"
+
+// enableDerivatives WebGL 1 compiles fwidth only when the extension is enabled on the context,
+// the shader draws the edges of the SDF atlases without it otherwise
+func enableDerivatives() {
+	engo.Gl.GetExtension("OES_standard_derivatives")
+}
@@ -0,0 +1,8 @@
+//go:build (android || ios) && !nogl
+// +build android ios
+// +build !nogl
+
+package engoutil
+
+// enableDerivatives the extensions of OpenGL ES are enabled by the shader, when the driver has them
+func enableDerivatives() {}
@@ -0,0 +1,6 @@
+//go:build nogl
+// +build nogl
+
+package engoutil
+
+func enableDerivatives() {}
@@ -20,9 +20,14 @@ const (
 	SHAPE_KIND_CURVE
 	SHAPE_KIND_TEXT
 	SHAPE_KIND_IMAGE
+	SHAPE_KIND_POLYLINE
+	SHAPE_KIND_ARROW
+	SHAPE_KIND_PATH
+	SHAPE_KIND_NINE_SLICE
+	SHAPE_KIND_SPRITE
 )
 
-var shapeKindName = [9]string{"Line", "StippleLine", "Rect", "StippleRect", "Circle", "Polygon", "Curve", "Text", "Image"}
+var shapeKindName = [14]string{"Line", "StippleLine", "Rect", "StippleRect", "Circle", "Polygon", "Curve", "Text", "Image", "Polyline", "Arrow", "Path", "NineSlice", "Sprite"}
 
 func (kind ShapeKind) String() string {
 	var s []string
@@ -88,6 +93,11 @@ type Shape struct {
 	// Polygon	2:w, 3:h
 	// Curve	2:w, 3:h
 	// Text		2:ax, 3:ay, 4:scale
+	// Polyline	2:w, 3:h
+	// Arrow	2:w, 3:h
+	// Path		2:w, 3:h
+	// NineSlice	2:w, 3:h
+	// Sprite	2:w, 3:h
 	attr [6]float32
 
 	onUpdate func(*Shape, float32)
@@ -95,8 +105,23 @@ type Shape struct {
 	onClick  func(*Shape)
 	onDrag   func(*Shape, float32, float32)
 
-	// SHAPE_KIND_STIPPLE_LINE, SHAPE_KIND_STIPPLE_RECT
+	// SHAPE_KIND_SPRITE
+	animation      *animation
+	onAnimationEnd func(*Shape)
+
+	// stippleKinds, nil until SetStipple for circles, polygons, curves and rects
 	stipple *Stipple
+
+	// drawn by the Canvas below the shape, text draws its own shadow
+	shadow *Shape
+
+	// kept on the drawable too, the shaders read it from there
+	blendMode BlendMode
+
+	// the mask of the group clips the mouse events
+	group *Group
+	// the mouse was over the shape in the last frame, inside the mask of its group
+	hovered bool
 }
 
 // implementation of common.BasicFace
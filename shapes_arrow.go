@@ -0,0 +1,246 @@
+package engoutil
+
+import (
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/math"
+)
+
+type ArrowMarker uint8
+
+const (
+	ARROW_MARKER_NONE ArrowMarker = iota
+	ARROW_MARKER_TRIANGLE
+	ARROW_MARKER_OPEN
+	ARROW_MARKER_CIRCLE
+	ARROW_MARKER_BAR
+)
+
+// The size of the markers is arrowMarkerScale times the line width
+const arrowMarkerScale = 5
+
+// Arrow is a line or polyline with markers on its ends
+type Arrow struct {
+	Polyline
+	// Head is drawn at the last point, Tail at the first point
+	Head, Tail ArrowMarker
+	Stipple    Stipple
+}
+
+// NewArrow
+// the head is drawn at the last point and the tail at the first point
+func NewArrow(points Points, lineWidth float32, head, tail ArrowMarker, clr uint32) *Shape {
+	return NewStippleArrow(points, 1, 0xFFFF, lineWidth, head, tail, clr)
+}
+
+// NewStippleArrow the line is dashed, markers are always solid
+func NewStippleArrow(points Points, factor int32, pattern uint16, lineWidth float32, head, tail ArrowMarker, clr uint32) *Shape {
+	s := newShape(SHAPE_KIND_ARROW)
+	s.stipple = &Stipple{
+		Factor:  factor,
+		Pattern: pattern,
+	}
+	a := &Arrow{
+		Polyline: Polyline{Points: points.Points(), LineWidth: lineWidth},
+		Head:     head,
+		Tail:     tail,
+		Stipple:  *s.stipple,
+	}
+	s.Render.Drawable = a
+	s.Render.Color = NewColor(clr)
+	s.Render.SetShader(ShapeHUDShader)
+	s.updateArrow(a)
+	return s
+}
+
+// (*Shape) SetEndpoints moves the first and the last points of an arrow
+func (s *Shape) SetEndpoints(x1, y1, x2, y2 float32) {
+	if !s.requireKind(SHAPE_KIND_ARROW, "SetEndpoints") {
+		return
+	}
+	a, ok := s.Render.Drawable.(*Arrow)
+	if !ok || len(a.Points) < 2 {
+		return
+	}
+	first, last := engo.Point{X: x1, Y: y1}, engo.Point{X: x2, Y: y2}
+	if a.Points[0] == first && a.Points[len(a.Points)-1] == last {
+		return
+	}
+	a.Points[0] = first
+	a.Points[len(a.Points)-1] = last
+	s.updateArrow(a)
+}
+
+// (*Shape) SetMarkers
+func (s *Shape) SetMarkers(head, tail ArrowMarker) {
+	if !s.requireKind(SHAPE_KIND_ARROW, "SetMarkers") {
+		return
+	}
+	if a, ok := s.Render.Drawable.(*Arrow); ok && (a.Head != head || a.Tail != tail) {
+		a.Head = head
+		a.Tail = tail
+		s.updateArrow(a)
+	}
+}
+
+func (s *Shape) updateArrow(a *Arrow) {
+	a.vertices = s.fitTriangles(a.tessellate())
+}
+
+func (a *Arrow) tessellate() (t triangles) {
+	n := len(a.Points)
+	if n < 2 {
+		return nil
+	}
+	w := a.LineWidth
+	line := trimPolyline(a.Points, a.Tail.inset(w), a.Head.inset(w))
+	for _, dash := range dashPolyline(line, a.Stipple) {
+		t = append(t, strokePolyline(dash, w, a.Join, a.Cap, false)...)
+	}
+	if i := previousPoint(a.Points, n-1, 1); i >= 0 {
+		t.marker(a.Points[i], a.Points[n-1], a.Head, w)
+	}
+	if i := previousPoint(a.Points, 0, -1); i >= 0 {
+		t.marker(a.Points[i], a.Points[0], a.Tail, w)
+	}
+	return
+}
+
+// previousPoint returns the index of the nearest point different from points[end],
+// step is 1 when looking backward from the end, -1 when looking forward from the start.
+func previousPoint(points []engo.Point, end, step int) int {
+	for i := end - step; i >= 0 && i < len(points); i -= step {
+		if points[i] != points[end] {
+			return i
+		}
+	}
+	return -1
+}
+
+// inset is how much the line is shortened to keep it from poking through the marker
+func (m ArrowMarker) inset(width float32) float32 {
+	size := width * arrowMarkerScale
+	switch m {
+	case ARROW_MARKER_TRIANGLE:
+		return size
+	case ARROW_MARKER_OPEN:
+		// the miter of the chevron ends at the tip
+		return width / 2 / math.Sin(math.Atan(0.5))
+	case ARROW_MARKER_CIRCLE:
+		// the corners of the line end on the circle
+		r, hw := size*0.4, width/2
+		return math.Sqrt(r*r - hw*hw)
+	case ARROW_MARKER_BAR:
+		return width
+	}
+	return 0
+}
+
+// marker adds the marker m at tip, pointing away from prev
+func (t *triangles) marker(prev, tip engo.Point, m ArrowMarker, width float32) {
+	var (
+		d    = direction(prev, tip)
+		nrm  = engo.Point{X: -d.Y, Y: d.X}
+		size = width * arrowMarkerScale
+		base = offset(tip, d, -size)
+	)
+	switch m {
+	case ARROW_MARKER_TRIANGLE:
+		t.add(tip, offset(base, nrm, size/2), offset(base, nrm, -size/2))
+	case ARROW_MARKER_OPEN:
+		apex := offset(tip, d, -m.inset(width))
+		base = offset(apex, d, -size)
+		*t = append(*t, strokePolyline([]engo.Point{offset(base, nrm, size/2), apex, offset(base, nrm, -size/2)}, width, LINE_JOIN_MITER, LINE_CAP_BUTT, false)...)
+	case ARROW_MARKER_CIRCLE:
+		t.fan(tip, size*0.4, 0, 2*math.Pi)
+	case ARROW_MARKER_BAR:
+		back := offset(tip, d, -width)
+		t.quad(offset(back, nrm, size/2), offset(tip, nrm, size/2), offset(tip, nrm, -size/2), offset(back, nrm, -size/2))
+	}
+}
+
+// trimPolyline cuts off the given lengths from both ends of the points
+func trimPolyline(points []engo.Point, start, end float32) []engo.Point {
+	points = cutPolyline(points, start)
+	reversed := make([]engo.Point, len(points))
+	for i, p := range points {
+		reversed[len(points)-1-i] = p
+	}
+	reversed = cutPolyline(reversed, end)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	return reversed
+}
+
+// cutPolyline removes the first `length` of the points
+func cutPolyline(points []engo.Point, length float32) []engo.Point {
+	if length <= 0 {
+		return append([]engo.Point(nil), points...)
+	}
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		d := math.Hypot(b.X-a.X, b.Y-a.Y)
+		if length < d {
+			return append([]engo.Point{offset(a, direction(a, b), length)}, points[i:]...)
+		}
+		length -= d
+	}
+	return nil
+}
+
+// dashPolyline splits the points into the dashes of the stipple pattern
+func dashPolyline(points []engo.Point, stipple Stipple) (dashes [][]engo.Point) {
+	runs, phase := stipple.runs()
+	if runs == nil || len(points) < 2 {
+		return [][]engo.Point{points}
+	}
+	k, left := runAt(runs, phase)
+	var dash []engo.Point
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		d := direction(a, b)
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		for t := float32(0); t < length; {
+			step := math.Min(left, length-t)
+			on := k%2 == 0
+			if on && step > 0 && len(dash) == 0 {
+				dash = append(dash, offset(a, d, t))
+			}
+			t += step
+			left -= step
+			if left <= 0 {
+				if on && len(dash) > 0 {
+					dashes = append(dashes, append(dash, offset(a, d, t)))
+					dash = nil
+				}
+				k = (k + 1) % len(runs)
+				left = runs[k]
+			}
+		}
+		// the dash turns the corner
+		if len(dash) > 0 {
+			dash = append(dash, b)
+		}
+	}
+	if len(dash) > 1 {
+		dashes = append(dashes, dash)
+	}
+	return
+}
+
+// runAt returns the index of the run at the distance, and what is left of it
+func runAt(runs []float32, distance float32) (k int, left float32) {
+	var period float32
+	for _, v := range runs {
+		period += v
+	}
+	distance = math.Mod(distance, period)
+	if distance < 0 {
+		distance += period
+	}
+	for k < len(runs)-1 && distance >= runs[k] {
+		distance -= runs[k]
+		k++
+	}
+	return k, runs[k] - distance
+}
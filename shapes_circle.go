@@ -51,10 +51,14 @@ func (s *Shape) SetArc(arc float32) {
 	if s.Render == nil || s.Render.Drawable == nil || s.attr[3] == arc {
 		return
 	}
-	if t, ok := s.Render.Drawable.(common.Circle); ok {
+	switch t := s.Render.Drawable.(type) {
+	case common.Circle:
 		s.attr[3] = arc
 		t.Arc = arc
 		s.Render.Drawable = t
+	case *Outline:
+		s.attr[3] = arc
+		s.updateOutline()
 	}
 }
 
@@ -70,9 +74,13 @@ func (s *Shape) AddArc(arc float32) {
 	if s.Render == nil || s.Render.Drawable == nil || s.attr[3] == arc {
 		return
 	}
-	if t, ok := s.Render.Drawable.(common.Circle); ok {
+	switch t := s.Render.Drawable.(type) {
+	case common.Circle:
 		s.attr[3] = arc
 		t.Arc = arc
 		s.Render.Drawable = t
+	case *Outline:
+		s.attr[3] = arc
+		s.updateOutline()
 	}
 }
@@ -33,7 +33,7 @@ func (s *Shape) Transform(x, y, width, height float32) {
 		s.Space.Position.Y = y
 		s.Space.Height = length
 		s.Space.Rotation = degrees
-	case SHAPE_KIND_STIPPLE_RECT, SHAPE_KIND_RECT, SHAPE_KIND_POLYGON, SHAPE_KIND_CURVE, SHAPE_KIND_IMAGE:
+	case SHAPE_KIND_STIPPLE_RECT, SHAPE_KIND_RECT, SHAPE_KIND_POLYGON, SHAPE_KIND_CURVE, SHAPE_KIND_IMAGE, SHAPE_KIND_NINE_SLICE, SHAPE_KIND_SPRITE:
 		s.Space.Position.X = x
 		s.Space.Position.Y = y
 		s.Space.Width = width
@@ -42,6 +42,14 @@ func (s *Shape) Transform(x, y, width, height float32) {
 		s.attr[1] = y
 		s.attr[2] = width
 		s.attr[3] = height
+		switch t := s.Render.Drawable.(type) {
+		case *NineSlice:
+			t.layout(width, height)
+		case StippleRect:
+			s.updateStippleVertices()
+		case *Outline:
+			s.updateOutline()
+		}
 	case SHAPE_KIND_CIRCLE:
 		s.attr[0] = x
 		s.attr[1] = y
@@ -50,6 +58,7 @@ func (s *Shape) Transform(x, y, width, height float32) {
 		s.Space.Position = engo.Point{X: x - width, Y: y - width}
 		s.Space.Width = size
 		s.Space.Height = size
+		s.updateOutline()
 	case SHAPE_KIND_TEXT:
 		s.attr[0] = x
 		s.attr[1] = y
@@ -78,7 +87,25 @@ func (s *Shape) Move(x, y float32) {
 		s.Space.Position.Y = y - s.attr[3] // -offsetY
 	case SHAPE_KIND_RECT:
 	case SHAPE_KIND_POLYGON:
-	case SHAPE_KIND_CURVE:
+	case SHAPE_KIND_CURVE, SHAPE_KIND_IMAGE, SHAPE_KIND_NINE_SLICE, SHAPE_KIND_SPRITE:
+		s.Space.Position.X = x
+		s.Space.Position.Y = y
+	// 折线移动的是左上角, 顶点跟随移动
+	case SHAPE_KIND_POLYLINE, SHAPE_KIND_ARROW, SHAPE_KIND_PATH:
+		var points []engo.Point
+		dx, dy := x-s.Space.Position.X, y-s.Space.Position.Y
+		switch t := s.Render.Drawable.(type) {
+		case *Polyline:
+			points = t.Points
+		case *Arrow:
+			points = t.Points
+		case *PathMesh:
+			t.Path.translate(dx, dy)
+		}
+		for i := range points {
+			points[i].X += dx
+			points[i].Y += dy
+		}
 		s.Space.Position.X = x
 		s.Space.Position.Y = y
 	case SHAPE_KIND_TEXT:
@@ -112,9 +139,9 @@ func (s *Shape) AddRotate(deg float32) {
 
 // (*Shape) SetPoints
 // 支持这些形状
-// SHAPE_KIND_STIPPLE_LINE, SHAPE_KIND_POLYGON, SHAPE_KIND_CURVE
+// SHAPE_KIND_STIPPLE_LINE, SHAPE_KIND_POLYGON, SHAPE_KIND_CURVE, SHAPE_KIND_POLYLINE, SHAPE_KIND_ARROW
 func (s *Shape) SetPoints(points Points) {
-	if !s.requireKind(SHAPE_KIND_STIPPLE_LINE|SHAPE_KIND_POLYGON|SHAPE_KIND_CURVE, "SetPoints") {
+	if !s.requireKind(SHAPE_KIND_STIPPLE_LINE|SHAPE_KIND_POLYGON|SHAPE_KIND_CURVE|SHAPE_KIND_POLYLINE|SHAPE_KIND_ARROW, "SetPoints") {
 		return
 	}
 	if s.Render == nil || s.Render.Drawable == nil {
@@ -125,6 +152,7 @@ func (s *Shape) SetPoints(points Points) {
 		if !points.Equal(t.Points) {
 			t.Points = points.Points()
 			s.Render.Drawable = t
+			s.updateStippleVertices()
 		}
 	case common.ComplexTriangles:
 		if !points.Equal(t.Points) {
@@ -136,6 +164,16 @@ func (s *Shape) SetPoints(points Points) {
 			t.Points = points.Points()
 			s.Render.Drawable = t
 		}
+	case *Outline:
+		t.Points = points.Points()
+		t.Contours = nil
+		s.updateOutline()
+	case *Polyline:
+		t.setPoints(points.Points())
+		s.updatePolyline(t)
+	case *Arrow:
+		t.Points = points.Points()
+		s.updateArrow(t)
 	}
 }
 
@@ -149,11 +187,13 @@ func (s *Shape) SetStrokeWidth(width float32) {
 		if t.BorderWidth != width {
 			t.BorderWidth = width
 			s.Render.Drawable = t
+			s.updateStippleVertices()
 		}
 	case StippleRect:
 		if t.BorderWidth != width {
 			t.BorderWidth = width
 			s.Render.Drawable = t
+			s.updateStippleVertices()
 		}
 	case common.Rectangle:
 		switch s.kind {
@@ -183,11 +223,71 @@ func (s *Shape) SetStrokeWidth(width float32) {
 			t.LineWidth = width
 			s.Render.Drawable = t
 		}
+	case *Outline:
+		if t.StrokeWidth != width {
+			t.StrokeWidth = width
+			s.updateOutline()
+		}
+	case *Polyline:
+		if t.LineWidth != width {
+			t.LineWidth = width
+			s.updatePolyline(t)
+		}
+	case *Arrow:
+		if t.LineWidth != width {
+			t.LineWidth = width
+			s.updateArrow(t)
+		}
+	case *PathMesh:
+		if t.LineWidth != width {
+			t.LineWidth = width
+			s.updatePath(t)
+		}
 	default:
 		warning("Shape(%s) SetStrokeWidth(), type %T not supported", s.kind, t)
 	}
 }
 
+// blendKinds lines are drawn by the legacy shader
+const blendKinds = ^SHAPE_KIND_LINE
+
+// (*Shape) SetBlendMode
+// the legacy shader has no blend modes: other than BLEND_NORMAL, rects, circles, polygons and curves
+// change their Render.Drawable to an *Outline drawn by the ShapeShader (ShapeHUDShader for HUD), as SetStipple.
+// The change is kept when the mode is set back to BLEND_NORMAL.
+func (s *Shape) SetBlendMode(mode BlendMode) {
+	if !s.requireKind(blendKinds, "SetBlendMode") {
+		return
+	}
+	s.blendMode = mode
+	if mode != BLEND_NORMAL {
+		switch s.Render.Drawable.(type) {
+		case common.Rectangle, common.Circle, common.ComplexTriangles, common.Curve:
+			s.toOutline()
+		}
+	}
+	s.updateBlendMode()
+}
+
+// updateBlendMode sets the mode to the drawable, after SetBlendMode or a new drawable
+func (s *Shape) updateBlendMode() {
+	switch t := s.Render.Drawable.(type) {
+	case StippleLine:
+		t.blend = s.blendMode
+		s.Render.Drawable = t
+	case StippleRect:
+		t.blend = s.blendMode
+		s.Render.Drawable = t
+	case interface{ setBlendMode(BlendMode) }:
+		t.setBlendMode(s.blendMode)
+	}
+}
+
+// (*Shape) BlendMode
+func (s *Shape) BlendMode() BlendMode {
+	return s.blendMode
+}
+
 // (*Shape) SetFillColor
 func (s *Shape) SetFillColor(clr uint32) {
 	if s.Render == nil {
@@ -230,6 +330,20 @@ func (s *Shape) SetStrokeColor(clr uint32) {
 		}
 	case common.Curve:
 		s.SetFillColor(clr)
+	case *Outline:
+		if t.StrokeColor == nil {
+			s.SetFillColor(clr)
+		} else if !t.StrokeColor.EqualUint32(clr) {
+			t.StrokeColor.Set(clr)
+		}
+	case *Polyline:
+		s.SetFillColor(clr)
+	case *Arrow:
+		s.SetFillColor(clr)
+	case *PathMesh:
+		if !t.StrokeColor.EqualUint32(clr) {
+			t.StrokeColor.Set(clr)
+		}
 	case *Text:
 		if !t.Color.EqualUint32(clr) {
 			t.Color.Set(clr)
@@ -238,3 +352,61 @@ func (s *Shape) SetStrokeColor(clr uint32) {
 		warning("Shape(%s) SetStrokeColor(), type %T not supported", s.kind, t)
 	}
 }
+
+// contains reports whether the point of the canvas is inside the shape, for the masks of the groups.
+// texts and images are their rects.
+func (s *Shape) contains(p engo.Point) bool {
+	// to the space of the shape, before its rotation
+	x, y := p.X-s.Space.Position.X, p.Y-s.Space.Position.Y
+	if s.Space.Rotation != 0 {
+		sin, cos := math.Sincos(s.Space.Rotation * math.Pi / 180)
+		x, y = x*cos+y*sin, y*cos-x*sin
+	}
+	w, h := s.Space.Width, s.Space.Height
+	if x < 0 || y < 0 || x > w || y > h {
+		return s.kind == SHAPE_KIND_POLYGON && s.polygonContains(x, y)
+	}
+	switch s.kind {
+	case SHAPE_KIND_CIRCLE:
+		r := w / 2
+		return (x-r)*(x-r)+(y-r)*(y-r) <= r*r
+	case SHAPE_KIND_RECT:
+		if _, ok := s.Render.Drawable.(*Outline); !ok {
+			return true
+		}
+		// the nearest corner
+		r := math.Min(math.Max(s.attr[4], 0), math.Min(w, h)/2)
+		cx, cy := math.Min(math.Max(x, r), w-r), math.Min(math.Max(y, r), h-r)
+		return (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r
+	case SHAPE_KIND_POLYGON:
+		return s.polygonContains(x, y)
+	}
+	return true
+}
+
+// polygonContains the point relative to the polygon is in one of its triangles
+func (s *Shape) polygonContains(x, y float32) bool {
+	var points []engo.Point
+	switch t := s.Render.Drawable.(type) {
+	case common.ComplexTriangles:
+		points = t.Points
+	case *Outline:
+		points = t.Points
+	}
+	w, h := s.Space.Width, s.Space.Height
+	// the side of the point from the edge a, b, the points are relative to the size
+	side := func(a, b engo.Point) float32 {
+		return (b.X-a.X)*w*(y-a.Y*h) - (b.Y-a.Y)*h*(x-a.X*w)
+	}
+	for i := 0; i+2 < len(points); i += 3 {
+		a, b, c := points[i], points[i+1], points[i+2]
+		if (b.X-a.X)*(c.Y-a.Y) == (b.Y-a.Y)*(c.X-a.X) {
+			continue
+		}
+		d1, d2, d3 := side(a, b), side(b, c), side(c, a)
+		if (d1 >= 0 && d2 >= 0 && d3 >= 0) || (d1 <= 0 && d2 <= 0 && d3 <= 0) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package engoutil
+
+import (
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/math"
+)
+
+// The generators return outlines relative to the size, as the points of the polygons,
+// centered in the box 0..1. GridLines and Ticks return the two ends of every line.
+
+var unitCenter = engo.Point{X: 0.5, Y: 0.5}
+
+func toPoint(p engo.Point) Point {
+	return Point{p.X, p.Y}
+}
+
+// RegularPolygon the outline of a regular polygon of n sides, the first vertex on top
+func RegularPolygon(n int) Points {
+	if n < 3 {
+		warning("RegularPolygon(), a polygon needs at least 3 sides, got %d", n)
+		return nil
+	}
+	points := make(Points, n)
+	for i := range points {
+		points[i] = toPoint(polar(unitCenter, 0.5, -halfPI+2*math.Pi*float32(i)/float32(n)))
+	}
+	return points
+}
+
+// Star the outline of a star of n points, the first point on top.
+// inner and outer are the radii of the valleys and of the points, the outer one fills the box
+func Star(n int, inner, outer float32) Points {
+	if n < 2 || outer <= 0 {
+		warning("Star(), a star needs at least 2 points and an outer radius, got %d, %v", n, outer)
+		return nil
+	}
+	r := 0.5 * inner / outer
+	points := make(Points, n*2)
+	for i := range points {
+		angle := -halfPI + math.Pi*float32(i)/float32(n)
+		if i%2 == 0 {
+			points[i] = toPoint(polar(unitCenter, 0.5, angle))
+		} else {
+			points[i] = toPoint(polar(unitCenter, r, angle))
+		}
+	}
+	return points
+}
+
+// Ring the outer and the inner circles of a ring of n segments, inner is the ratio of the inner radius 0..1.
+// the inner circle is a hole, the contours are for NewPolygonContours
+func Ring(inner float32, n int) []Points {
+	if n < 3 {
+		n = 3
+	}
+	outer := RegularPolygon(n)
+	if inner <= 0 {
+		return []Points{outer}
+	}
+	hole := make(Points, n)
+	for i := range hole {
+		hole[i] = toPoint(polar(unitCenter, 0.5*math.Min(inner, 1), -halfPI+2*math.Pi*float32(i)/float32(n)))
+	}
+	return []Points{outer, hole}
+}
+
+// Cross the outline of a plus sign, thickness is the width of the bars 0..1
+func Cross(thickness float32) Points {
+	a, b := 0.5-thickness/2, 0.5+thickness/2
+	return Points{{a, 0}, {b, 0}, {b, a}, {1, a}, {1, b}, {b, b}, {b, 1}, {a, 1}, {a, b}, {0, b}, {0, a}, {a, a}}
+}
+
+// Checkmark the outline of a check mark, thickness is the width of the strokes 0..1
+func Checkmark(thickness float32) Points {
+	hw := thickness / 2
+	// the middle of the strokes, inside the box by half of the thickness
+	start := engo.Point{X: hw, Y: 0.55}
+	corner := engo.Point{X: 0.38, Y: 1 - hw}
+	end := engo.Point{X: 1 - hw, Y: hw}
+
+	d0, d1 := direction(start, corner), direction(corner, end)
+	n0, n1 := engo.Point{X: -d0.Y, Y: d0.X}, engo.Point{X: -d1.Y, Y: d1.X}
+	m := direction(engo.Point{}, engo.Point{X: n0.X + n1.X, Y: n0.Y + n1.Y})
+	miter := hw / (m.X*n0.X + m.Y*n0.Y)
+	outline := []engo.Point{
+		offset(start, n0, hw),
+		offset(corner, m, miter),
+		offset(end, n1, hw),
+		offset(end, n1, -hw),
+		offset(corner, m, -miter),
+		offset(start, n0, -hw),
+	}
+	// the corners stick out of the box, it is stretched back into it
+	min, max := outline[0], outline[0]
+	for _, p := range outline {
+		min.X, min.Y = math.Min(min.X, p.X), math.Min(min.Y, p.Y)
+		max.X, max.Y = math.Max(max.X, p.X), math.Max(max.Y, p.Y)
+	}
+	points := make(Points, len(outline))
+	for i, p := range outline {
+		points[i] = Point{(p.X - min.X) / (max.X - min.X), (p.Y - min.Y) / (max.Y - min.Y)}
+	}
+	return points
+}
+
+// GridLines the lines of a grid of cols x rows cells, the borders included.
+// the vertical lines come first, every line is two points.
+func GridLines(cols, rows int) Points {
+	if cols < 1 || rows < 1 {
+		return nil
+	}
+	points := make(Points, 0, (cols+rows+2)*2)
+	for i := 0; i <= cols; i++ {
+		x := float32(i) / float32(cols)
+		points = append(points, Point{x, 0}, Point{x, 1})
+	}
+	for i := 0; i <= rows; i++ {
+		y := float32(i) / float32(rows)
+		points = append(points, Point{0, y}, Point{1, y})
+	}
+	return points
+}
+
+// Ticks n radial lines around the center, the first one on top, as the marks of a dial.
+// inner and outer are the ratios of the radius 0..1 where the lines start and end, every line is two points.
+func Ticks(n int, inner, outer float32) Points {
+	if n < 1 {
+		warning("Ticks(), a dial needs at least 1 tick, got %d", n)
+		return nil
+	}
+	points := make(Points, 0, n*2)
+	for i := 0; i < n; i++ {
+		angle := -halfPI + 2*math.Pi*float32(i)/float32(n)
+		points = append(points, toPoint(polar(unitCenter, 0.5*inner, angle)), toPoint(polar(unitCenter, 0.5*outer, angle)))
+	}
+	return points
+}
+
+// NewRegularPolygon see RegularPolygon
+func NewRegularPolygon(x, y, width, height float32, n int, strokeWidth float32, strokeColor, fillColor uint32) *Shape {
+	return NewPolygonContours(x, y, width, height, strokeWidth, []Points{RegularPolygon(n)}, strokeColor, fillColor)
+}
+
+// NewStar see Star
+func NewStar(x, y, width, height float32, n int, inner, outer, strokeWidth float32, strokeColor, fillColor uint32) *Shape {
+	return NewPolygonContours(x, y, width, height, strokeWidth, []Points{Star(n, inner, outer)}, strokeColor, fillColor)
+}
+
+// NewRing see Ring, the circles are smooth within a quarter pixel
+func NewRing(x, y, width, height, inner, strokeWidth float32, strokeColor, fillColor uint32) *Shape {
+	n := arcSegments(math.Max(width, height)/2, 2*math.Pi)
+	return NewPolygonContours(x, y, width, height, strokeWidth, Ring(inner, n), strokeColor, fillColor)
+}
+
+// NewGridLines see GridLines, the lines are lineWidth pixels wide
+func NewGridLines(x, y, width, height float32, cols, rows int, lineWidth float32, clr uint32) *Shape {
+	return newLinesShape(x, y, width, height, GridLines(cols, rows), lineWidth, clr)
+}
+
+// NewTicks see Ticks
+func NewTicks(x, y, width, height float32, n int, inner, outer, lineWidth float32, clr uint32) *Shape {
+	return newLinesShape(x, y, width, height, Ticks(n, inner, outer), lineWidth, clr)
+}
+
+// newLinesShape a path of the separate lines, the points are the ends of the lines relative to the size
+func newLinesShape(x, y, width, height float32, lines Points, lineWidth float32, clr uint32) *Shape {
+	path := NewPath()
+	for i := 0; i+1 < len(lines); i += 2 {
+		a, b := lines[i], lines[i+1]
+		path.MoveTo(x+a[0]*width, y+a[1]*height).LineTo(x+b[0]*width, y+b[1]*height)
+	}
+	return NewPathShape(path, lineWidth, clr, 0)
+}
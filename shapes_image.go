@@ -0,0 +1,207 @@
+package engoutil
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/gl"
+)
+
+type ImageFilter uint8
+
+const (
+	IMAGE_FILTER_LINEAR ImageFilter = iota
+	IMAGE_FILTER_NEAREST
+)
+
+var _ common.Drawable = (*Image)(nil)
+
+// Image is a region of a texture, as used by the `ImageShader`.
+// The color of the RenderComponent tints the image.
+type Image struct {
+	texture *gl.Texture
+	// the size of the whole texture in pixels
+	textureWidth, textureHeight float32
+	// owned textures are uploaded from an image.Image, and deleted by Close
+	owned bool
+
+	// Region is the source sub-rectangle in pixels
+	Region engo.AABB
+	FlipX  bool
+	FlipY  bool
+	// Filter the images sharing a texture share one filter, the texture is filtered again when they differ
+	Filter ImageFilter
+	blending
+}
+
+func (i *Image) Texture() *gl.Texture { return i.texture }
+func (i *Image) Width() float32       { return i.Region.Max.X - i.Region.Min.X }
+func (i *Image) Height() float32      { return i.Region.Max.Y - i.Region.Min.Y }
+
+// View returns the texture coordinates of the region, swapped when flipped
+func (i *Image) View() (u, v, u2, v2 float32) {
+	if i.textureWidth == 0 || i.textureHeight == 0 {
+		return 0, 0, 1, 1
+	}
+	u, u2 = i.Region.Min.X/i.textureWidth, i.Region.Max.X/i.textureWidth
+	v, v2 = i.Region.Min.Y/i.textureHeight, i.Region.Max.Y/i.textureHeight
+	if i.FlipX {
+		u, u2 = u2, u
+	}
+	if i.FlipY {
+		v, v2 = v2, v
+	}
+	return
+}
+
+// Close deletes the texture, when it was uploaded by the image
+func (i *Image) Close() {
+	if i.owned && i.texture != nil && !engo.Headless() {
+		engo.Gl.DeleteTexture(i.texture)
+		delete(textureFilters, i.texture)
+	}
+	i.texture = nil
+}
+
+// newImageSource loads the source,
+// an `engo.Files` URL, an image.Image, a common.Texture or a *common.Texture
+func newImageSource(source interface{}) (*Image, error) {
+	switch src := source.(type) {
+	case string:
+		tex, err := common.LoadedSprite(src)
+		if err != nil {
+			return nil, err
+		}
+		return newImageTexture(*tex), nil
+	case *common.Texture:
+		return newImageTexture(*src), nil
+	case common.Texture:
+		return newImageTexture(src), nil
+	case image.Image:
+		bounds := src.Bounds()
+		nrgba, ok := src.(*image.NRGBA)
+		if !ok || bounds.Min != (image.Point{}) {
+			nrgba = image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+			draw.Draw(nrgba, nrgba.Bounds(), src, bounds.Min, draw.Src)
+		}
+		w, h := float32(bounds.Dx()), float32(bounds.Dy())
+		return &Image{
+			texture:       common.UploadTexture(common.NewImageObject(nrgba)),
+			textureWidth:  w,
+			textureHeight: h,
+			owned:         true,
+			Region:        engo.AABB{Max: engo.Point{X: w, Y: h}},
+		}, nil
+	}
+	return nil, fmt.Errorf("image source of type %T not supported", source)
+}
+
+// newImageTexture keeps the viewport of the texture, e.g. a cell of a sprite sheet
+func newImageTexture(tex common.Texture) *Image {
+	u, v, u2, v2 := tex.View()
+	img := &Image{texture: tex.Texture(), textureWidth: tex.Width(), textureHeight: tex.Height()}
+	if u2 != u && v2 != v {
+		img.textureWidth /= u2 - u
+		img.textureHeight /= v2 - v
+	}
+	img.Region = engo.AABB{
+		Min: engo.Point{X: u * img.textureWidth, Y: v * img.textureHeight},
+		Max: engo.Point{X: u2 * img.textureWidth, Y: v2 * img.textureHeight},
+	}
+	return img
+}
+
+// NewImage
+// source is an `engo.Files` URL, an image.Image, a common.Texture or a *common.Texture.
+// zero width or height uses the size of the source.
+func NewImage(x, y, width, height float32, source interface{}) *Shape {
+	s := newShape(SHAPE_KIND_IMAGE)
+	img, err := newImageSource(source)
+	if err != nil {
+		warning("NewImage(). Error was: %s", err.Error())
+		img = &Image{}
+	}
+	if width == 0 {
+		width = img.Width()
+	}
+	if height == 0 {
+		height = img.Height()
+	}
+	s.Render.Drawable = img
+	s.Render.Color = NewColor(0xFFFFFFFF)
+	s.Render.SetShader(ImageHUDShader)
+	s.Transform(x, y, width, height)
+	return s
+}
+
+// (*Shape) SetImage replaces the source, keeps the size, flip and filter
+func (s *Shape) SetImage(source interface{}) {
+	if !s.requireKind(SHAPE_KIND_IMAGE|SHAPE_KIND_NINE_SLICE, "SetImage") {
+		return
+	}
+	img, err := newImageSource(source)
+	if err != nil {
+		warning("(Shape) SetImage(). Error was: %s", err.Error())
+		return
+	}
+	if old := s.image(); old != nil {
+		old.Close()
+		img.FlipX, img.FlipY, img.Filter = old.FlipX, old.FlipY, old.Filter
+	}
+	switch d := s.Render.Drawable.(type) {
+	case *Image:
+		s.Render.Drawable = img
+	case *NineSlice:
+		d.Image = img
+		d.layout(s.attr[2], s.attr[3])
+	}
+	s.updateBlendMode()
+}
+
+// (*Shape) SetImageRegion sets the source sub-rectangle in pixels
+func (s *Shape) SetImageRegion(x, y, width, height float32) {
+	if !s.requireKind(SHAPE_KIND_IMAGE|SHAPE_KIND_NINE_SLICE, "SetImageRegion") {
+		return
+	}
+	if img := s.image(); img != nil {
+		img.Region = engo.AABB{Min: engo.Point{X: x, Y: y}, Max: engo.Point{X: x + width, Y: y + height}}
+	}
+	if n, ok := s.Render.Drawable.(*NineSlice); ok {
+		n.layout(s.attr[2], s.attr[3])
+	}
+}
+
+// (*Shape) SetFlip mirrors the image horizontally and/or vertically
+func (s *Shape) SetFlip(flipX, flipY bool) {
+	if !s.requireKind(SHAPE_KIND_IMAGE|SHAPE_KIND_SPRITE, "SetFlip") {
+		return
+	}
+	if img := s.image(); img != nil {
+		img.FlipX = flipX
+		img.FlipY = flipY
+	}
+}
+
+// (*Shape) SetFilter IMAGE_FILTER_LINEAR or IMAGE_FILTER_NEAREST
+// the filter is a parameter of the texture, the images sharing a texture share one filter
+func (s *Shape) SetFilter(filter ImageFilter) {
+	if !s.requireKind(SHAPE_KIND_IMAGE|SHAPE_KIND_NINE_SLICE|SHAPE_KIND_SPRITE, "SetFilter") {
+		return
+	}
+	if img := s.image(); img != nil {
+		img.Filter = filter
+	}
+}
+
+func (s *Shape) image() *Image {
+	switch d := s.Render.Drawable.(type) {
+	case *Image:
+		return d
+	case *NineSlice:
+		return d.Image
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package engoutil
+
+import (
+	"github.com/EngoEngine/math"
+)
+
+type NineSliceMode uint8
+
+const (
+	// NINE_SLICE_STRETCH stretches the edges
+	NINE_SLICE_STRETCH NineSliceMode = iota
+	// NINE_SLICE_TILE repeats the edges, a whole number of tiles is scaled to fill them, the tiles are never cropped
+	NINE_SLICE_TILE
+)
+
+// NineSlice is an image split by Insets into 9 parts,
+// the corners keep their size, the edges are stretched or tiled, the center is stretched.
+type NineSlice struct {
+	*Image
+	Insets Padding
+	Mode   NineSliceMode
+
+	// quads of 16 values: x, y, u, v for each corner
+	quads []float32
+}
+
+// sliceSpan is a part of one axis, in pixels of the destination and of the source
+type sliceSpan struct {
+	dst, dstLen float32
+	src, srcLen float32
+}
+
+// NewNineSlice
+// source is an `engo.Files` URL, an image.Image, a common.Texture or a *common.Texture.
+// insets are the sizes of the borders in pixels of the source.
+func NewNineSlice(x, y, width, height float32, source interface{}, insets Padding) *Shape {
+	s := newShape(SHAPE_KIND_NINE_SLICE)
+	img, err := newImageSource(source)
+	if err != nil {
+		warning("NewNineSlice(). Error was: %s", err.Error())
+		img = &Image{}
+	}
+	s.Render.Drawable = &NineSlice{Image: img, Insets: insets}
+	s.Render.Color = NewColor(0xFFFFFFFF)
+	s.Render.SetShader(ImageHUDShader)
+	s.Transform(x, y, width, height)
+	return s
+}
+
+// (*Shape) SetInsets
+func (s *Shape) SetInsets(insets Padding) {
+	if !s.requireKind(SHAPE_KIND_NINE_SLICE, "SetInsets") {
+		return
+	}
+	if n, ok := s.Render.Drawable.(*NineSlice); ok && n.Insets != insets {
+		n.Insets = insets
+		n.layout(s.attr[2], s.attr[3])
+	}
+}
+
+// (*Shape) SetNineSliceMode NINE_SLICE_STRETCH or NINE_SLICE_TILE
+func (s *Shape) SetNineSliceMode(mode NineSliceMode) {
+	if !s.requireKind(SHAPE_KIND_NINE_SLICE, "SetNineSliceMode") {
+		return
+	}
+	if n, ok := s.Render.Drawable.(*NineSlice); ok && n.Mode != mode {
+		n.Mode = mode
+		n.layout(s.attr[2], s.attr[3])
+	}
+}
+
+// layout splits the size into the quads, all of them share one buffer
+func (n *NineSlice) layout(width, height float32) {
+	n.quads = n.quads[:0]
+	if n.Image == nil || n.textureWidth == 0 || n.textureHeight == 0 {
+		return
+	}
+	region := n.Region
+	columns := sliceAxis(width, region.Min.X, region.Max.X-region.Min.X, n.Insets.Left, n.Insets.Right, n.Mode)
+	rows := sliceAxis(height, region.Min.Y, region.Max.Y-region.Min.Y, n.Insets.Top, n.Insets.Bottom, n.Mode)
+	// the center is always stretched
+	center := [2][]sliceSpan{
+		sliceAxis(width, region.Min.X, region.Max.X-region.Min.X, n.Insets.Left, n.Insets.Right, NINE_SLICE_STRETCH)[1],
+		sliceAxis(height, region.Min.Y, region.Max.Y-region.Min.Y, n.Insets.Top, n.Insets.Bottom, NINE_SLICE_STRETCH)[1],
+	}
+
+	for j, row := range rows {
+		for i, column := range columns {
+			xs, ys := column, row
+			if i == 1 && j == 1 {
+				xs, ys = center[0], center[1]
+			}
+			for _, y := range ys {
+				for _, x := range xs {
+					if x.dstLen <= 0 || y.dstLen <= 0 || len(n.quads) >= bufferSize*16 {
+						continue
+					}
+					n.quads = append(n.quads,
+						x.dst, y.dst, x.src/n.textureWidth, y.src/n.textureHeight,
+						x.dst+x.dstLen, y.dst, (x.src+x.srcLen)/n.textureWidth, y.src/n.textureHeight,
+						x.dst+x.dstLen, y.dst+y.dstLen, (x.src+x.srcLen)/n.textureWidth, (y.src+y.srcLen)/n.textureHeight,
+						x.dst, y.dst+y.dstLen, x.src/n.textureWidth, (y.src+y.srcLen)/n.textureHeight,
+					)
+				}
+			}
+		}
+	}
+}
+
+// sliceAxis returns the spans of the 3 parts of one axis,
+// the borders shrink proportionally when the size is smaller than their sum.
+func sliceAxis(size, src, srcLen, start, end float32, mode NineSliceMode) (parts [3][]sliceSpan) {
+	start = math.Min(math.Max(start, 0), srcLen)
+	end = math.Min(math.Max(end, 0), srcLen-start)
+	dstStart, dstEnd := start, end
+	if sum := start + end; sum > size && sum > 0 {
+		dstStart = size * start / sum
+		dstEnd = size - dstStart
+	}
+	middle, srcMiddle := size-dstStart-dstEnd, srcLen-start-end
+
+	parts[0] = []sliceSpan{{dst: 0, dstLen: dstStart, src: src, srcLen: start}}
+	parts[2] = []sliceSpan{{dst: size - dstEnd, dstLen: dstEnd, src: src + srcLen - end, srcLen: end}}
+	if mode != NINE_SLICE_TILE || srcMiddle < 1 {
+		parts[1] = []sliceSpan{{dst: dstStart, dstLen: middle, src: src + start, srcLen: srcMiddle}}
+		return
+	}
+	// the nearest whole number of tiles, at least one, scaled to the middle
+	n := math.Max(1, math.Floor(middle/srcMiddle+0.5))
+	tile := middle / n
+	for i := float32(0); i < n; i++ {
+		parts[1] = append(parts[1], sliceSpan{dst: dstStart + i*tile, dstLen: tile, src: src + start, srcLen: srcMiddle})
+	}
+	return
+}
@@ -0,0 +1,309 @@
+package engoutil
+
+import (
+	"image/color"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/gl"
+	"github.com/EngoEngine/math"
+)
+
+var _ common.Drawable = (*Outline)(nil)
+
+// Outline is the fill and the dashed stroke of a circle, a polygon, a curve or a rect,
+// drawn by the shape shader instead of the legacy shader.
+type Outline struct {
+	StrokeWidth float32
+	// nil uses the color of the RenderComponent, as curves do
+	StrokeColor *Color
+	Stipple     Stipple
+	// Polygon: the triangles, relative to the size; Curve: the control points
+	Points []engo.Point
+	// Polygon: the outlines of SetContours, relative to the size. nil when the Points are given as triangles
+	Contours [][]engo.Point
+
+	// x, y, distance. the fill first, then the stroke
+	vertices []float32
+	fill     int
+	blending
+}
+
+func (*Outline) Texture() *gl.Texture                       { return nil }
+func (*Outline) Width() float32                             { return 0 }
+func (*Outline) Height() float32                            { return 0 }
+func (*Outline) View() (float32, float32, float32, float32) { return 0, 0, 1, 1 }
+func (*Outline) Close()                                     {}
+
+func (o *Outline) dashVertices(*common.SpaceComponent) []float32 { return o.vertices }
+func (o *Outline) stipple() Stipple                              { return o.Stipple }
+
+// NewRoundRect
+// the stroke is inside the rect, as NewRect
+func NewRoundRect(x, y, width, height, radius, strokeWidth float32, strokeColor, fillColor uint32) *Shape {
+	s := newShape(SHAPE_KIND_RECT)
+	s.attr[4] = radius
+	s.Render.Drawable = &Outline{StrokeWidth: strokeWidth, StrokeColor: NewColor(strokeColor)}
+	s.Render.Color = NewColor(fillColor)
+	s.Render.SetShader(ShapeHUDShader)
+	s.Transform(x, y, width, height)
+	return s
+}
+
+// (*Shape) SetCornerRadius sets the radius of the corners of a rect
+func (s *Shape) SetCornerRadius(radius float32) {
+	if !s.requireKind(SHAPE_KIND_RECT, "SetCornerRadius") || s.attr[4] == radius {
+		return
+	}
+	s.attr[4] = radius
+	s.toOutline()
+	s.updateOutline()
+}
+
+// toOutline replaces the drawable of the legacy shader, keeps the stroke and the points
+func (s *Shape) toOutline() *Outline {
+	if o, ok := s.Render.Drawable.(*Outline); ok {
+		return o
+	}
+	o := &Outline{}
+	switch t := s.Render.Drawable.(type) {
+	case common.Rectangle:
+		o.StrokeWidth, o.StrokeColor = t.BorderWidth, outlineColor(t.BorderColor)
+	case common.Circle:
+		o.StrokeWidth, o.StrokeColor = t.BorderWidth, outlineColor(t.BorderColor)
+	case common.ComplexTriangles:
+		o.StrokeWidth, o.StrokeColor = t.BorderWidth, outlineColor(t.BorderColor)
+		o.Points = t.Points
+	case common.Curve:
+		o.StrokeWidth = t.LineWidth
+		o.Points = t.Points
+	}
+	if s.Render.Shader() == common.LegacyShader {
+		s.Render.SetShader(ShapeShader)
+	} else {
+		s.Render.SetShader(ShapeHUDShader)
+	}
+	s.Render.Drawable = o
+	s.updateBlendMode()
+	s.updateOutline()
+	return o
+}
+
+func outlineColor(clr color.Color) *Color {
+	if clr == nil {
+		return NewColor(0)
+	}
+	return ParseColor(clr)
+}
+
+// updateOutline tessellates the outline after the size, the points or the stroke width changed
+func (s *Shape) updateOutline() {
+	o, ok := s.Render.Drawable.(*Outline)
+	if !ok {
+		return
+	}
+	var (
+		fill  triangles
+		paths [][]engo.Point
+		// closed paths, curves and arcs are open
+		closed = true
+		bw     = math.Max(o.StrokeWidth, 0)
+		w, h   = s.Space.Width, s.Space.Height
+	)
+	switch s.kind {
+	case SHAPE_KIND_RECT:
+		// the stroke is inside, centered on the rect inset by half of its width
+		radius := math.Min(math.Max(s.attr[4], 0), math.Min(w, h)/2)
+		fill = fanTriangles(roundRectPoints(bw, bw, w-bw, h-bw, radius-bw))
+		if bw > 0 {
+			paths = append(paths, roundRectPoints(bw/2, bw/2, w-bw/2, h-bw/2, radius-bw/2))
+		}
+	case SHAPE_KIND_CIRCLE:
+		radius, sweep := s.attr[2], s.attr[3]*math.Pi/180
+		center := engo.Point{X: radius, Y: radius}
+		if inner := radius - bw; inner > 0 {
+			fill.fan(center, inner, 0, sweep)
+		}
+		if bw > 0 {
+			paths = append(paths, arcPoints(center, radius-bw/2, sweep))
+			closed = s.attr[3] >= 360
+		}
+	case SHAPE_KIND_POLYGON:
+		points := make([]engo.Point, len(o.Points))
+		for i, p := range o.Points {
+			points[i] = engo.Point{X: p.X * w, Y: p.Y * h}
+			fill = append(fill, points[i].X, points[i].Y)
+		}
+		fill = fill[:len(fill)/6*6]
+		if bw > 0 {
+			paths = o.contours(w, h)
+		}
+	case SHAPE_KIND_CURVE:
+		paths = append(paths, curvePoints(o.Points, w, h))
+		closed = false
+	}
+
+	o.vertices = o.vertices[:0]
+	for i := 0; i < len(fill); i += 2 {
+		o.vertices = append(o.vertices, fill[i], fill[i+1], 0)
+	}
+	o.fill = len(o.vertices)
+	for _, path := range paths {
+		o.vertices = append(o.vertices, strokeDashes(path, bw, LINE_JOIN_MITER, LINE_CAP_BUTT, closed)...)
+	}
+}
+
+// contours the outlines of a polygon, scaled to the size
+func (o *Outline) contours(w, h float32) [][]engo.Point {
+	if o.Contours == nil {
+		points := make([]engo.Point, len(o.Points))
+		for i, p := range o.Points {
+			points[i] = engo.Point{X: p.X * w, Y: p.Y * h}
+		}
+		return triangleBoundary(points)
+	}
+	contours := make([][]engo.Point, len(o.Contours))
+	for i, c := range o.Contours {
+		contours[i] = make([]engo.Point, len(c))
+		for j, p := range c {
+			contours[i][j] = engo.Point{X: p.X * w, Y: p.Y * h}
+		}
+	}
+	return contours
+}
+
+// fanTriangles fills a convex contour from its first point
+func fanTriangles(points []engo.Point) (t triangles) {
+	for i := 2; i < len(points); i++ {
+		t.add(points[0], points[i-1], points[i])
+	}
+	return
+}
+
+// arcPoints clockwise from the right of the center, as the legacy circle
+func arcPoints(center engo.Point, radius, sweep float32) []engo.Point {
+	full := sweep >= 2*math.Pi
+	n := arcSegments(radius, sweep)
+	if full && n < 3 {
+		n = 3
+	}
+	points := make([]engo.Point, 0, n+1)
+	for i := 0; i <= n; i++ {
+		if full && i == n {
+			// closed, without repeating the first point
+			break
+		}
+		points = append(points, polar(center, radius, sweep*float32(i)/float32(n)))
+	}
+	return points
+}
+
+// roundRectPoints clockwise, from the end of the top edge
+func roundRectPoints(x0, y0, x1, y1, radius float32) []engo.Point {
+	if x1 <= x0 || y1 <= y0 {
+		return nil
+	}
+	radius = math.Min(math.Max(radius, 0), math.Min(x1-x0, y1-y0)/2)
+	if radius == 0 {
+		return []engo.Point{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+	}
+	var (
+		points  []engo.Point
+		n       = arcSegments(radius, math.Pi/2)
+		centers = [4]engo.Point{
+			{X: x1 - radius, Y: y0 + radius},
+			{X: x1 - radius, Y: y1 - radius},
+			{X: x0 + radius, Y: y1 - radius},
+			{X: x0 + radius, Y: y0 + radius},
+		}
+	)
+	for corner, center := range centers {
+		from := float32(corner-1) * math.Pi / 2
+		for i := 0; i <= n; i++ {
+			points = append(points, polar(center, radius, from+math.Pi/2*float32(i)/float32(n)))
+		}
+	}
+	return points
+}
+
+// curvePoints samples the legacy curve, from 0, 0 to width, height
+func curvePoints(controls []engo.Point, width, height float32) []engo.Point {
+	const steps = 100
+	points := make([]engo.Point, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		t := float32(i) / steps
+		u := 1 - t
+		var p engo.Point
+		switch len(controls) {
+		case 0:
+			p = engo.Point{X: t * width, Y: t * height}
+		case 1:
+			p.X = 2*u*t*controls[0].X + t*t*width
+			p.Y = 2*u*t*controls[0].Y + t*t*height
+		default:
+			p.X = 3*u*u*t*controls[0].X + 3*u*t*t*controls[1].X + t*t*t*width
+			p.Y = 3*u*u*t*controls[0].Y + 3*u*t*t*controls[1].Y + t*t*t*height
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// triangleBoundary returns the closed contours of a list of triangles,
+// made of the edges belonging to one triangle only.
+func triangleBoundary(points []engo.Point) (contours [][]engo.Point) {
+	type edge [2]engo.Point
+	count := make(map[edge]int)
+	var edges []edge
+	for i := 0; i+2 < len(points); i += 3 {
+		for j := 0; j < 3; j++ {
+			a, b := points[i+j], points[i+(j+1)%3]
+			if a == b {
+				continue
+			}
+			// undirected
+			if b.X < a.X || b.X == a.X && b.Y < a.Y {
+				a, b = b, a
+			}
+			e := edge{a, b}
+			if count[e] == 0 {
+				edges = append(edges, e)
+			}
+			count[e]++
+		}
+	}
+	next := make(map[engo.Point][]engo.Point)
+	for _, e := range edges {
+		if count[e] == 1 {
+			next[e[0]] = append(next[e[0]], e[1])
+			next[e[1]] = append(next[e[1]], e[0])
+		}
+	}
+	// take the edge from p to one of its neighbors
+	take := func(p engo.Point) (engo.Point, bool) {
+		for len(next[p]) > 0 {
+			q := next[p][0]
+			next[p] = next[p][1:]
+			// remove the reverse
+			for i, r := range next[q] {
+				if r == p {
+					next[q] = append(next[q][:i], next[q][i+1:]...)
+					return q, true
+				}
+			}
+		}
+		return engo.Point{}, false
+	}
+	for _, e := range edges {
+		start := e[0]
+		if count[e] != 1 || len(next[start]) == 0 {
+			continue
+		}
+		contour := []engo.Point{start}
+		for p, ok := take(start); ok && p != start; p, ok = take(p) {
+			contour = append(contour, p)
+		}
+		contours = append(contours, contour)
+	}
+	return
+}
@@ -0,0 +1,399 @@
+package engoutil
+
+import (
+	"sort"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/gl"
+	"github.com/EngoEngine/math"
+)
+
+type FillRule uint8
+
+const (
+	FILL_RULE_NONZERO FillRule = iota
+	FILL_RULE_EVENODD
+)
+
+// Curves are flattened until they are within pathTolerance pixels of the line segments
+const pathTolerance = 0.25
+
+type subpath struct {
+	points []engo.Point
+	closed bool
+}
+
+// Path is a builder of vector outlines, made of one or more subpaths.
+// Curves are flattened as they are added.
+type Path struct {
+	FillRule FillRule
+	Join     LineJoin
+	Cap      LineCap
+
+	subpaths []subpath
+}
+
+// NewPath creates an empty path
+func NewPath() *Path {
+	return &Path{}
+}
+
+func (p *Path) current() *subpath {
+	if len(p.subpaths) == 0 || p.subpaths[len(p.subpaths)-1].closed {
+		var start engo.Point
+		if n := len(p.subpaths); n > 0 {
+			start = p.subpaths[n-1].points[0]
+		}
+		p.subpaths = append(p.subpaths, subpath{points: []engo.Point{start}})
+	}
+	return &p.subpaths[len(p.subpaths)-1]
+}
+
+func (p *Path) last() engo.Point {
+	points := p.current().points
+	return points[len(points)-1]
+}
+
+// (*Path) MoveTo starts a new subpath
+func (p *Path) MoveTo(x, y float32) *Path {
+	if n := len(p.subpaths); n > 0 && !p.subpaths[n-1].closed && len(p.subpaths[n-1].points) == 1 {
+		// consecutive MoveTo replaces the start point
+		p.subpaths[n-1].points[0] = engo.Point{X: x, Y: y}
+		return p
+	}
+	p.subpaths = append(p.subpaths, subpath{points: []engo.Point{{X: x, Y: y}}})
+	return p
+}
+
+// (*Path) LineTo
+func (p *Path) LineTo(x, y float32) *Path {
+	sp := p.current()
+	sp.points = append(sp.points, engo.Point{X: x, Y: y})
+	return p
+}
+
+// (*Path) QuadTo adds a quadratic Bézier curve with the control point cx, cy
+func (p *Path) QuadTo(cx, cy, x, y float32) *Path {
+	p0, p1, p2 := p.last(), engo.Point{X: cx, Y: cy}, engo.Point{X: x, Y: y}
+	// the chord error of n segments is |p0 - 2p1 + p2| / (4n²)
+	dd := math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y)
+	n := curveSegments(dd / 4)
+	sp := p.current()
+	for i := 1; i <= n; i++ {
+		t := float32(i) / float32(n)
+		u := 1 - t
+		sp.points = append(sp.points, engo.Point{
+			X: u*u*p0.X + 2*u*t*p1.X + t*t*p2.X,
+			Y: u*u*p0.Y + 2*u*t*p1.Y + t*t*p2.Y,
+		})
+	}
+	return p
+}
+
+// (*Path) CubicTo adds a cubic Bézier curve with the control points c1 and c2
+func (p *Path) CubicTo(c1x, c1y, c2x, c2y, x, y float32) *Path {
+	p0, p1, p2, p3 := p.last(), engo.Point{X: c1x, Y: c1y}, engo.Point{X: c2x, Y: c2y}, engo.Point{X: x, Y: y}
+	// the second derivative is bounded by 6 * max|second differences|, the chord error by that * h² / 8
+	dd := math.Max(
+		math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y),
+		math.Hypot(p1.X-2*p2.X+p3.X, p1.Y-2*p2.Y+p3.Y),
+	)
+	n := curveSegments(dd * 3 / 4)
+	sp := p.current()
+	for i := 1; i <= n; i++ {
+		t := float32(i) / float32(n)
+		u := 1 - t
+		a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+		sp.points = append(sp.points, engo.Point{
+			X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+			Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+		})
+	}
+	return p
+}
+
+// curveSegments returns n for a chord error of k/n², keeping it within pathTolerance
+func curveSegments(k float32) int {
+	n := int(math.Ceil(math.Sqrt(k / pathTolerance)))
+	if n < 1 {
+		return 1
+	}
+	if n > 256 {
+		return 256
+	}
+	return n
+}
+
+// (*Path) ArcTo adds an elliptical arc, same as the SVG "A" command.
+// rotation is in degrees, largeArc and sweep choose one of the four possible arcs.
+func (p *Path) ArcTo(rx, ry, rotation float32, largeArc, sweep bool, x, y float32) *Path {
+	p0 := p.last()
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || (p0.X == x && p0.Y == y) {
+		return p.LineTo(x, y)
+	}
+	// https://www.w3.org/TR/SVG11/implnote.html#ArcConversionEndpointToCenter
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+	dx, dy := (p0.X-x)/2, (p0.Y-y)/2
+	x1 := cos*dx + sin*dy
+	y1 := -sin*dx + cos*dy
+	// scale up the radii when there is no solution
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx *= math.Sqrt(l)
+		ry *= math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	k := math.Sqrt(math.Max(num, 0) / den)
+	if largeArc == sweep {
+		k = -k
+	}
+	cx1, cy1 := k*rx*y1/ry, -k*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (p0.X+x)/2
+	cy := sin*cx1 + cos*cy1 + (p0.Y+y)/2
+
+	start := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	delta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - start
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+
+	n := arcSegments(math.Max(rx, ry), delta)
+	sp := p.current()
+	for i := 1; i < n; i++ {
+		es, ec := math.Sincos(start + delta*float32(i)/float32(n))
+		sp.points = append(sp.points, engo.Point{
+			X: cx + cos*rx*ec - sin*ry*es,
+			Y: cy + sin*rx*ec + cos*ry*es,
+		})
+	}
+	// end exactly on the target point
+	sp.points = append(sp.points, engo.Point{X: x, Y: y})
+	return p
+}
+
+// (*Path) Close closes the current subpath, the next one starts from its first point
+func (p *Path) Close() *Path {
+	if n := len(p.subpaths); n > 0 && !p.subpaths[n-1].closed {
+		p.subpaths[n-1].closed = true
+	}
+	return p
+}
+
+// contours returns the subpaths with more than one point
+func (p *Path) contours() (contours [][]engo.Point) {
+	for _, sp := range p.subpaths {
+		if len(sp.points) > 1 {
+			contours = append(contours, sp.points)
+		}
+	}
+	return
+}
+
+var _ common.Drawable = (*PathMesh)(nil)
+
+// PathMesh is the drawable of a path shape, the fill and the stroke are tessellated on the CPU
+type PathMesh struct {
+	Path        *Path
+	LineWidth   float32
+	StrokeColor *Color
+
+	vertices []float32
+	// the fill triangles are at the start of vertices, followed by the stroke triangles
+	fill int
+	blending
+}
+
+func (PathMesh) Texture() *gl.Texture                       { return nil }
+func (PathMesh) Width() float32                             { return 0 }
+func (PathMesh) Height() float32                            { return 0 }
+func (PathMesh) View() (float32, float32, float32, float32) { return 0, 0, 1, 1 }
+func (PathMesh) Close()                                     {}
+
+func (m *PathMesh) triangles() []float32 { return m.vertices }
+
+// NewPathShape fills the closed and the open subpaths of the path, and strokes them.
+// the path is copied, later changes of it need SetPath
+func NewPathShape(path *Path, strokeWidth float32, strokeColor, fillColor uint32) *Shape {
+	s := newShape(SHAPE_KIND_PATH)
+	m := &PathMesh{LineWidth: strokeWidth, StrokeColor: NewColor(strokeColor)}
+	s.Render.Drawable = m
+	s.Render.Color = NewColor(fillColor)
+	s.Render.SetShader(ShapeHUDShader)
+	s.SetPath(path)
+	return s
+}
+
+// (*Shape) SetPath replaces the outline of a path shape
+func (s *Shape) SetPath(path *Path) {
+	if !s.requireKind(SHAPE_KIND_PATH, "SetPath") {
+		return
+	}
+	if m, ok := s.Render.Drawable.(*PathMesh); ok {
+		m.Path = path.clone()
+		s.updatePath(m)
+	}
+}
+
+// (*Shape) SetFillRule
+func (s *Shape) SetFillRule(rule FillRule) {
+	if !s.requireKind(SHAPE_KIND_PATH, "SetFillRule") {
+		return
+	}
+	if m, ok := s.Render.Drawable.(*PathMesh); ok && m.Path.FillRule != rule {
+		m.Path.FillRule = rule
+		s.updatePath(m)
+	}
+}
+
+func (p *Path) clone() *Path {
+	c := *p
+	c.subpaths = make([]subpath, len(p.subpaths))
+	for i, sp := range p.subpaths {
+		c.subpaths[i] = subpath{points: append([]engo.Point(nil), sp.points...), closed: sp.closed}
+	}
+	return &c
+}
+
+func (p *Path) translate(dx, dy float32) {
+	for _, sp := range p.subpaths {
+		for i := range sp.points {
+			sp.points[i].X += dx
+			sp.points[i].Y += dy
+		}
+	}
+}
+
+func (s *Shape) updatePath(m *PathMesh) {
+	vertices := fillContours(m.Path.contours(), m.Path.FillRule)
+	m.fill = len(vertices)
+	if m.LineWidth > 0 {
+		for _, sp := range m.Path.subpaths {
+			vertices = append(vertices, strokePolyline(sp.points, m.LineWidth, m.Path.Join, m.Path.Cap, sp.closed)...)
+		}
+	}
+	m.vertices = s.fitTriangles(vertices)
+}
+
+// fillEdge is a non-horizontal edge going down from (x0, y0) to (x1, y1)
+type fillEdge struct {
+	x0, y0, x1, y1 float32
+	// +1 when the contour goes down, -1 when it goes up
+	winding int
+}
+
+func (e fillEdge) xAt(y float32) float32 {
+	t := math.Clamp((y-e.y0)/(e.y1-e.y0), 0, 1)
+	return e.x0 + (e.x1-e.x0)*t
+}
+
+// intersectY returns the y of the crossing of two edges
+func (e fillEdge) intersectY(o fillEdge) (float32, bool) {
+	if e.y1 <= o.y0 || o.y1 <= e.y0 {
+		return 0, false
+	}
+	rx, ry := e.x1-e.x0, e.y1-e.y0
+	sx, sy := o.x1-o.x0, o.y1-o.y0
+	den := rx*sy - ry*sx
+	if den == 0 {
+		return 0, false
+	}
+	qx, qy := o.x0-e.x0, o.y0-e.y0
+	t := (qx*sy - qy*sx) / den
+	u := (qx*ry - qy*rx) / den
+	if t <= 0 || t >= 1 || u <= 0 || u >= 1 {
+		return 0, false
+	}
+	return e.y0 + t*ry, true
+}
+
+type fillCrossing struct {
+	top, bottom, middle float32
+	winding             int
+}
+
+// fillContours triangulates the area inside the contours by the fill rule.
+// The plane is cut into horizontal slabs at every vertex and every crossing of edges,
+// inside a slab the edges don't cross, and the filled spans between them are trapezoids.
+func fillContours(contours [][]engo.Point, rule FillRule) (t triangles) {
+	var (
+		edges []fillEdge
+		ys    []float32
+	)
+	for _, c := range contours {
+		for i, a := range c {
+			b := c[(i+1)%len(c)]
+			ys = append(ys, a.Y)
+			switch {
+			case a.Y < b.Y:
+				edges = append(edges, fillEdge{a.X, a.Y, b.X, b.Y, 1})
+			case a.Y > b.Y:
+				edges = append(edges, fillEdge{b.X, b.Y, a.X, a.Y, -1})
+			}
+		}
+	}
+	if len(edges) < 2 {
+		return nil
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+	for i, e := range edges {
+		for _, o := range edges[i+1:] {
+			if o.y0 >= e.y1 {
+				break
+			}
+			if y, ok := e.intersectY(o); ok {
+				ys = append(ys, y)
+			}
+		}
+	}
+	sort.Slice(ys, func(i, j int) bool { return ys[i] < ys[j] })
+
+	const epsilon = 1e-4
+	var crossings []fillCrossing
+	for k := 1; k < len(ys); k++ {
+		top, bottom := ys[k-1], ys[k]
+		if bottom-top < epsilon {
+			ys[k] = top
+			continue
+		}
+		middle := (top + bottom) / 2
+		crossings = crossings[:0]
+		for _, e := range edges {
+			if e.y0 > middle {
+				break
+			}
+			if e.y1 > middle {
+				crossings = append(crossings, fillCrossing{e.xAt(top), e.xAt(bottom), e.xAt(middle), e.winding})
+			}
+		}
+		sort.Slice(crossings, func(i, j int) bool { return crossings[i].middle < crossings[j].middle })
+
+		var (
+			winding int
+			start   fillCrossing
+		)
+		for _, c := range crossings {
+			before := rule.inside(winding)
+			winding += c.winding
+			if after := rule.inside(winding); !before && after {
+				start = c
+			} else if before && !after {
+				t.quad(
+					engo.Point{X: start.top, Y: top}, engo.Point{X: c.top, Y: top},
+					engo.Point{X: c.bottom, Y: bottom}, engo.Point{X: start.bottom, Y: bottom},
+				)
+			}
+		}
+	}
+	return
+}
+
+func (rule FillRule) inside(winding int) bool {
+	if rule == FILL_RULE_EVENODD {
+		return winding%2 != 0
+	}
+	return winding != 0
+}
@@ -1,8 +1,11 @@
 package engoutil
 
 import (
+	"sort"
+
 	"github.com/EngoEngine/engo"
 	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/math"
 )
 
 // NewPolygon this is ComplexTriangles
@@ -20,3 +23,305 @@ func NewPolygon(x, y, width, height float32, strokeWidth float32, points Points,
 	s.Space.Height = height
 	return s
 }
+
+// NewPolygonContours
+// the contours are outlines relative to the size, as the points of NewPolygon, the contours inside others are holes.
+func NewPolygonContours(x, y, width, height, strokeWidth float32, contours []Points, strokeColor, fillColor uint32) *Shape {
+	s := newShape(SHAPE_KIND_POLYGON)
+	s.attr[0] = x
+	s.attr[1] = y
+	s.attr[2] = width
+	s.attr[3] = height
+	s.Render.Drawable = &Outline{StrokeWidth: strokeWidth, StrokeColor: NewColor(strokeColor)}
+	s.Render.Color = NewColor(fillColor)
+	s.Render.SetShader(ShapeHUDShader)
+	s.Space.Position = engo.Point{X: x, Y: y}
+	s.Space.Width = width
+	s.Space.Height = height
+	s.SetContours(contours...)
+	return s
+}
+
+// (*Shape) SetContours replaces the triangles of the polygon by outlines, see NewPolygonContours.
+// they are triangulated by ear clipping, the contours crossing each other are filled by the even-odd rule.
+func (s *Shape) SetContours(contours ...Points) {
+	if !s.requireKind(SHAPE_KIND_POLYGON, "SetContours") {
+		return
+	}
+	o := s.toOutline()
+	o.Contours = make([][]engo.Point, 0, len(contours))
+	for _, c := range contours {
+		o.Contours = append(o.Contours, c.Points())
+	}
+	o.Points = triangulateContours(o.Contours)
+	s.updateOutline()
+}
+
+// triangulateContours returns the triangles of the area inside the contours
+func triangulateContours(contours [][]engo.Point) []engo.Point {
+	var rings [][]engo.Point
+	for _, c := range contours {
+		if ring, _ := uniquePoints(c, true); len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+	}
+	if contoursIntersect(rings) {
+		return trianglePoints(fillContours(rings, FILL_RULE_EVENODD))
+	}
+	// a simple contour without area is a line
+	n := 0
+	for _, ring := range rings {
+		if ringArea(ring) != 0 {
+			rings[n] = ring
+			n++
+		}
+	}
+	rings = rings[:n]
+
+	// the depth is the number of contours around a contour, the odd ones are holes of the contour just around them
+	depth := make([]int, len(rings))
+	for i, ring := range rings {
+		for j, other := range rings {
+			if i != j && ringContains(other, ring[0]) {
+				depth[i]++
+			}
+		}
+	}
+	var result []engo.Point
+	for i, outer := range rings {
+		if depth[i]%2 == 1 {
+			continue
+		}
+		outer = orientRing(outer, true)
+		var holes [][]engo.Point
+		for j, hole := range rings {
+			if depth[j] == depth[i]+1 && ringContains(rings[i], hole[0]) {
+				holes = append(holes, orientRing(hole, false))
+			}
+		}
+		// from right to left, so a bridge never crosses the holes not bridged yet
+		sort.Slice(holes, func(a, b int) bool { return ringMaxX(holes[a]) > ringMaxX(holes[b]) })
+		for _, hole := range holes {
+			outer = bridgeHole(outer, hole)
+		}
+		t, ok := earClip(outer)
+		if !ok {
+			// rounding errors left a polygon without ears
+			return trianglePoints(fillContours(rings, FILL_RULE_EVENODD))
+		}
+		result = append(result, t...)
+	}
+	return result
+}
+
+// earClip cuts the triangles of a simple polygon with a positive area, bridged holes included
+func earClip(ring []engo.Point) (t []engo.Point, ok bool) {
+	points := append([]engo.Point(nil), ring...)
+	for i, misses := 0, 0; len(points) > 3; {
+		n := len(points)
+		i %= n
+		a, b, c := points[(i+n-1)%n], points[i], points[(i+1)%n]
+		switch cross := (b.X-a.X)*(c.Y-b.Y) - (b.Y-a.Y)*(c.X-b.X); {
+		case cross == 0:
+			// collinear, nothing to fill
+		case cross > 0 && isEar(points, a, b, c):
+			t = append(t, a, b, c)
+		default:
+			i++
+			if misses++; misses > n {
+				return t, false
+			}
+			continue
+		}
+		points = append(points[:i], points[i+1:]...)
+		misses = 0
+	}
+	if len(points) == 3 && ringArea(points) > 0 {
+		t = append(t, points...)
+	}
+	return t, true
+}
+
+// isEar no other point of the polygon is inside the triangle, the points of the bridges appear twice
+func isEar(points []engo.Point, a, b, c engo.Point) bool {
+	for _, p := range points {
+		if p == a || p == b || p == c {
+			continue
+		}
+		d1 := (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+		d2 := (c.X-b.X)*(p.Y-b.Y) - (c.Y-b.Y)*(p.X-b.X)
+		d3 := (a.X-c.X)*(p.Y-c.Y) - (a.Y-c.Y)*(p.X-c.X)
+		if d1 >= 0 && d2 >= 0 && d3 >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bridgeHole joins the hole to the outer contour by two edges, from its rightmost point to a visible point
+func bridgeHole(outer, hole []engo.Point) []engo.Point {
+	m := 0
+	for i, p := range hole {
+		if p.X > hole[m].X {
+			m = i
+		}
+	}
+	mp := hole[m]
+
+	// the nearest edge on the right of m
+	var (
+		n             = len(outer)
+		p             = -1
+		nearX float32 = math.MaxFloat32
+	)
+	for i := range outer {
+		a, b := outer[i], outer[(i+1)%n]
+		if a.Y == b.Y || math.Min(a.Y, b.Y) > mp.Y || math.Max(a.Y, b.Y) < mp.Y {
+			continue
+		}
+		x := a.X + (mp.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+		if x < mp.X || x >= nearX {
+			continue
+		}
+		nearX = x
+		switch {
+		case x == a.X && mp.Y == a.Y:
+			p = i
+		case x == b.X && mp.Y == b.Y:
+			p = (i + 1) % n
+		case a.X > b.X:
+			p = i
+		default:
+			p = (i + 1) % n
+		}
+	}
+	if p < 0 {
+		return outer
+	}
+
+	// a point inside the triangle m, the hit, p hides p, the one closest to the ray is visible
+	var (
+		hit              = engo.Point{X: nearX, Y: mp.Y}
+		pp               = outer[p]
+		best             = p
+		bestTan  float32 = math.MaxFloat32
+		inside           = func(q engo.Point) bool { return pointInTriangle(q, mp, hit, pp) || pointInTriangle(q, mp, pp, hit) }
+		bestDist float32 = math.MaxFloat32
+	)
+	if pp.Y != mp.Y {
+		for i, q := range outer {
+			if i == p || q.X < mp.X || !inside(q) {
+				continue
+			}
+			tan, dist := math.Abs(q.Y-mp.Y)/math.Max(q.X-mp.X, 1e-6), q.X-mp.X
+			if tan < bestTan || tan == bestTan && dist < bestDist {
+				best, bestTan, bestDist = i, tan, dist
+			}
+		}
+	}
+
+	merged := make([]engo.Point, 0, len(outer)+len(hole)+2)
+	merged = append(merged, outer[:best+1]...)
+	for i := 0; i <= len(hole); i++ {
+		merged = append(merged, hole[(m+i)%len(hole)])
+	}
+	merged = append(merged, outer[best:]...)
+	return merged
+}
+
+// pointInTriangle the edges included, for the clockwise triangles of a positive area
+func pointInTriangle(p, a, b, c engo.Point) bool {
+	d1 := (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+	d2 := (c.X-b.X)*(p.Y-b.Y) - (c.Y-b.Y)*(p.X-b.X)
+	d3 := (a.X-c.X)*(p.Y-c.Y) - (a.Y-c.Y)*(p.X-c.X)
+	return d1 >= 0 && d2 >= 0 && d3 >= 0
+}
+
+// ringArea twice the signed area
+func ringArea(ring []engo.Point) (area float32) {
+	for i, a := range ring {
+		b := ring[(i+1)%len(ring)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return
+}
+
+// orientRing returns the ring with a positive area, or a negative one
+func orientRing(ring []engo.Point, positive bool) []engo.Point {
+	if (ringArea(ring) > 0) == positive {
+		return ring
+	}
+	reversed := make([]engo.Point, len(ring))
+	for i, p := range ring {
+		reversed[len(ring)-1-i] = p
+	}
+	return reversed
+}
+
+func ringMaxX(ring []engo.Point) float32 {
+	x := ring[0].X
+	for _, p := range ring {
+		x = math.Max(x, p.X)
+	}
+	return x
+}
+
+// ringContains by the even-odd rule
+func ringContains(ring []engo.Point, p engo.Point) (inside bool) {
+	for i, a := range ring {
+		b := ring[(i+1)%len(ring)]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return
+}
+
+// contoursIntersect reports whether two edges cross or touch, other than the neighbors of a contour
+func contoursIntersect(rings [][]engo.Point) bool {
+	type edge struct {
+		a, b        engo.Point
+		ring, index int
+	}
+	var edges []edge
+	for r, ring := range rings {
+		for i, a := range ring {
+			edges = append(edges, edge{a, ring[(i+1)%len(ring)], r, i})
+		}
+	}
+	orient := func(a, b, c engo.Point) float32 {
+		return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+	}
+	onSegment := func(a, b, p engo.Point) bool {
+		return math.Min(a.X, b.X) <= p.X && p.X <= math.Max(a.X, b.X) && math.Min(a.Y, b.Y) <= p.Y && p.Y <= math.Max(a.Y, b.Y)
+	}
+	for i, e := range edges {
+		for _, f := range edges[i+1:] {
+			if e.ring == f.ring {
+				n := len(rings[e.ring])
+				if f.index == e.index+1 || e.index == 0 && f.index == n-1 {
+					continue
+				}
+			}
+			d1, d2 := orient(f.a, f.b, e.a), orient(f.a, f.b, e.b)
+			d3, d4 := orient(e.a, e.b, f.a), orient(e.a, e.b, f.b)
+			if (d1 > 0 && d2 < 0 || d1 < 0 && d2 > 0) && (d3 > 0 && d4 < 0 || d3 < 0 && d4 > 0) {
+				return true
+			}
+			if d1 == 0 && onSegment(f.a, f.b, e.a) || d2 == 0 && onSegment(f.a, f.b, e.b) ||
+				d3 == 0 && onSegment(e.a, e.b, f.a) || d4 == 0 && onSegment(e.a, e.b, f.b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// trianglePoints the points of the triangles, 3 by triangle
+func trianglePoints(t triangles) []engo.Point {
+	points := make([]engo.Point, len(t)/2)
+	for i := range points {
+		points[i] = engo.Point{X: t[i*2], Y: t[i*2+1]}
+	}
+	return points
+}
@@ -0,0 +1,75 @@
+package engoutil
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/math"
+)
+
+func TestTriangulateContours(t *testing.T) {
+	square := func(x0, y0, x1, y1 float32) []engo.Point {
+		return []engo.Point{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+	}
+	reverse := func(ring []engo.Point) []engo.Point {
+		reversed := make([]engo.Point, len(ring))
+		for i, p := range ring {
+			reversed[len(ring)-1-i] = p
+		}
+		return reversed
+	}
+	lShape := []engo.Point{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 2}, {X: 0, Y: 2}}
+
+	for _, c := range []struct {
+		name      string
+		contours  [][]engo.Point
+		area      float32
+		triangles int
+	}{
+		{"square", [][]engo.Point{square(0, 0, 1, 1)}, 1, 2},
+		{"clockwise square", [][]engo.Point{reverse(square(0, 0, 1, 1))}, 1, 2},
+		{"L-shape", [][]engo.Point{lShape}, 3, 4},
+		{"closed square", [][]engo.Point{append(square(0, 0, 1, 1), engo.Point{})}, 1, 2},
+		// the holes are bridged to the contours around them
+		{"ring", [][]engo.Point{square(0, 0, 4, 4), square(1, 1, 3, 3)}, 12, 8},
+		{"ring of the same orientation", [][]engo.Point{square(0, 0, 4, 4), reverse(square(1, 1, 3, 3))}, 12, 8},
+		{"two holes", [][]engo.Point{square(0, 0, 5, 3), square(1, 1, 2, 2), square(3, 1, 4, 2)}, 13, 11},
+		{"island in a hole", [][]engo.Point{square(0, 0, 6, 6), square(1, 1, 5, 5), square(2, 2, 4, 4)}, 24, 10},
+		{"separate squares", [][]engo.Point{square(0, 0, 1, 1), square(2, 0, 3, 1)}, 2, 4},
+		{"line", [][]engo.Point{{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}}}, 0, 0},
+	} {
+		points := triangulateContours(c.contours)
+		if len(points) != 3*c.triangles {
+			t.Errorf("%s: %d points, want %d triangles", c.name, len(points), c.triangles)
+			continue
+		}
+		var area float32
+		for i := 0; i+2 < len(points); i += 3 {
+			a := ringArea(points[i:i+3]) / 2
+			area += math.Abs(a)
+			// the triangles are inside the contours by the even-odd rule
+			center := engo.Point{X: (points[i].X + points[i+1].X + points[i+2].X) / 3, Y: (points[i].Y + points[i+1].Y + points[i+2].Y) / 3}
+			inside := false
+			for _, ring := range c.contours {
+				inside = inside != ringContains(ring, center)
+			}
+			if !inside || a == 0 {
+				t.Errorf("%s: triangle %v is outside or empty", c.name, points[i:i+3])
+			}
+		}
+		if math.Abs(area-c.area) > 1e-4 {
+			t.Errorf("%s: area %v, want %v", c.name, area, c.area)
+		}
+	}
+
+	// the crossing edges of a bow tie are filled by the even-odd rule
+	bowTie := [][]engo.Point{{{X: 0, Y: 0}, {X: 2, Y: 2}, {X: 2, Y: 0}, {X: 0, Y: 2}}}
+	var area float32
+	points := triangulateContours(bowTie)
+	for i := 0; i+2 < len(points); i += 3 {
+		area += math.Abs(ringArea(points[i:i+3]) / 2)
+	}
+	if math.Abs(area-2) > 1e-4 {
+		t.Errorf("bow tie: area %v, want 2", area)
+	}
+}
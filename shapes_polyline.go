@@ -0,0 +1,274 @@
+package engoutil
+
+import (
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/gl"
+	"github.com/EngoEngine/math"
+)
+
+type LineJoin uint8
+
+const (
+	LINE_JOIN_MITER LineJoin = iota
+	LINE_JOIN_ROUND
+	LINE_JOIN_BEVEL
+)
+
+type LineCap uint8
+
+const (
+	LINE_CAP_BUTT LineCap = iota
+	LINE_CAP_ROUND
+	LINE_CAP_SQUARE
+)
+
+// A miter longer than miterLimit * lineWidth / 2 falls back to bevel
+const miterLimit = 4
+
+var _ common.Drawable = (*Polyline)(nil)
+
+// Polyline is a stroke of connected segments, tessellated on the CPU
+type Polyline struct {
+	Points    []engo.Point
+	LineWidth float32
+	Join      LineJoin
+	Cap       LineCap
+	// Closed connects the last point to the first one, no caps are drawn
+	Closed bool
+
+	// triangles, relative to the position of the SpaceComponent
+	vertices []float32
+	blending
+}
+
+func (Polyline) Texture() *gl.Texture                       { return nil }
+func (Polyline) Width() float32                             { return 0 }
+func (Polyline) Height() float32                            { return 0 }
+func (Polyline) View() (float32, float32, float32, float32) { return 0, 0, 1, 1 }
+func (Polyline) Close()                                     {}
+
+// NewPolyline
+// the path is closed when the first and the last points are equal
+func NewPolyline(points Points, width float32, join LineJoin, lineCap LineCap, clr uint32) *Shape {
+	s := newShape(SHAPE_KIND_POLYLINE)
+	p := &Polyline{LineWidth: width, Join: join, Cap: lineCap}
+	p.setPoints(points.Points())
+	s.Render.Drawable = p
+	s.Render.Color = NewColor(clr)
+	s.Render.SetShader(ShapeHUDShader)
+	s.updatePolyline(p)
+	return s
+}
+
+func (p *Polyline) setPoints(points []engo.Point) {
+	n := len(points)
+	p.Closed = n > 2 && points[0] == points[n-1]
+	if p.Closed {
+		points = points[:n-1]
+	}
+	p.Points = points
+}
+
+func (p *Polyline) triangles() []float32 { return p.vertices }
+
+func (s *Shape) updatePolyline(p *Polyline) {
+	p.vertices = s.fitTriangles(strokePolyline(p.Points, p.LineWidth, p.Join, p.Cap, p.Closed))
+}
+
+// fitTriangles moves the vertices relative to their bounds, and fits the SpaceComponent to them
+func (s *Shape) fitTriangles(vertices triangles) triangles {
+	min, max := verticesBounds(vertices)
+	for i := 0; i < len(vertices); i += 2 {
+		vertices[i] -= min.X
+		vertices[i+1] -= min.Y
+	}
+	s.attr[0] = min.X
+	s.attr[1] = min.Y
+	s.attr[2] = max.X - min.X
+	s.attr[3] = max.Y - min.Y
+	s.Space.Position = min
+	s.Space.Width = s.attr[2]
+	s.Space.Height = s.attr[3]
+	return vertices
+}
+
+func verticesBounds(vertices []float32) (min, max engo.Point) {
+	if len(vertices) < 2 {
+		return
+	}
+	min = engo.Point{X: vertices[0], Y: vertices[1]}
+	max = min
+	for i := 2; i < len(vertices); i += 2 {
+		min.X = math.Min(min.X, vertices[i])
+		min.Y = math.Min(min.Y, vertices[i+1])
+		max.X = math.Max(max.X, vertices[i])
+		max.Y = math.Max(max.Y, vertices[i+1])
+	}
+	return
+}
+
+// triangles is a list of vertices, every 6 values are a triangle
+type triangles []float32
+
+func (t *triangles) add(a, b, c engo.Point) {
+	*t = append(*t, a.X, a.Y, b.X, b.Y, c.X, c.Y)
+}
+
+func (t *triangles) quad(a, b, c, d engo.Point) {
+	t.add(a, b, c)
+	t.add(a, c, d)
+}
+
+// fan adds a circular sector, starting from angle `from` and turning `sweep` radians
+func (t *triangles) fan(center engo.Point, radius, from, sweep float32) {
+	n := arcSegments(radius, sweep)
+	step := sweep / float32(n)
+	prev := polar(center, radius, from)
+	for i := 1; i <= n; i++ {
+		next := polar(center, radius, from+step*float32(i))
+		t.add(center, prev, next)
+		prev = next
+	}
+}
+
+// arcSegments returns the number of segments keeping the arc within a quarter pixel
+func arcSegments(radius, sweep float32) int {
+	if radius <= 0.25 {
+		return 1
+	}
+	n := int(math.Ceil(math.Abs(sweep) / (2 * math.Acos(1-0.25/radius))))
+	if n < 1 {
+		return 1
+	}
+	if n > 128 {
+		return 128
+	}
+	return n
+}
+
+func polar(center engo.Point, radius, angle float32) engo.Point {
+	sin, cos := math.Sincos(angle)
+	return engo.Point{X: center.X + cos*radius, Y: center.Y + sin*radius}
+}
+
+// direction returns the unit vector from a to b
+func direction(a, b engo.Point) engo.Point {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return engo.Point{}
+	}
+	return engo.Point{X: dx / length, Y: dy / length}
+}
+
+// offset returns p + v * scale
+func offset(p, v engo.Point, scale float32) engo.Point {
+	return engo.Point{X: p.X + v.X*scale, Y: p.Y + v.Y*scale}
+}
+
+// strokePolyline tessellates the points into triangles of the given width
+func strokePolyline(points []engo.Point, width float32, join LineJoin, lineCap LineCap, closed bool) triangles {
+	unique, closed := uniquePoints(points, closed)
+	n := len(unique)
+	if n < 2 || width <= 0 {
+		return nil
+	}
+
+	var (
+		t        triangles
+		hw       = width / 2
+		segments = n - 1
+	)
+	if closed {
+		segments = n
+	}
+	for i := 0; i < segments; i++ {
+		a, b := unique[i], unique[(i+1)%n]
+		d := direction(a, b)
+		nrm := engo.Point{X: -d.Y, Y: d.X}
+		t.quad(offset(a, nrm, hw), offset(b, nrm, hw), offset(b, nrm, -hw), offset(a, nrm, -hw))
+	}
+
+	first, last := 1, n-1
+	if closed {
+		first, last = 0, n
+	}
+	for i := first; i < last; i++ {
+		t.join(unique[(i-1+n)%n], unique[i], unique[(i+1)%n], hw, join)
+	}
+
+	if !closed {
+		t.cap(unique[1], unique[0], hw, lineCap)
+		t.cap(unique[n-2], unique[n-1], hw, lineCap)
+	}
+	return t
+}
+
+// uniquePoints skips repeated points, they have no direction.
+// a closed path needs at least 3 points.
+func uniquePoints(points []engo.Point, closed bool) ([]engo.Point, bool) {
+	unique := make([]engo.Point, 0, len(points))
+	for i, p := range points {
+		if i == 0 || p != unique[len(unique)-1] {
+			unique = append(unique, p)
+		}
+	}
+	if closed && len(unique) > 1 && unique[0] == unique[len(unique)-1] {
+		unique = unique[:len(unique)-1]
+	}
+	return unique, closed && len(unique) > 2
+}
+
+// join fills the gap on the outer side of the corner p
+func (t *triangles) join(prev, p, next engo.Point, hw float32, join LineJoin) {
+	d0, d1 := direction(prev, p), direction(p, next)
+	cross := d0.X*d1.Y - d0.Y*d1.X
+	dot := d0.X*d1.X + d0.Y*d1.Y
+	if math.Abs(cross) < 1e-6 {
+		if dot > 0 {
+			// straight through
+			return
+		}
+		// turning back, round joins sweep a half circle
+		cross = 0
+	}
+	side := float32(1)
+	if cross < 0 {
+		side = -1
+	}
+	n0 := engo.Point{X: -d0.Y, Y: d0.X}
+	n1 := engo.Point{X: -d1.Y, Y: d1.X}
+	// outer corners of the two segments
+	o0, o1 := offset(p, n0, -side*hw), offset(p, n1, -side*hw)
+
+	switch join {
+	case LINE_JOIN_ROUND:
+		from := math.Atan2(o0.Y-p.Y, o0.X-p.X)
+		// the angle between o0 and o1 equals the angle between the segments
+		t.fan(p, hw, from, math.Atan2(cross, dot))
+		return
+	case LINE_JOIN_MITER:
+		m := direction(engo.Point{}, engo.Point{X: n0.X + n1.X, Y: n0.Y + n1.Y})
+		if cos := m.X*n0.X + m.Y*n0.Y; cos > 1.0/miterLimit {
+			tip := offset(p, m, -side*hw/cos)
+			t.add(p, o0, tip)
+			t.add(p, tip, o1)
+			return
+		}
+	}
+	t.add(p, o0, o1)
+}
+
+// cap closes the open end of the segment from -> to
+func (t *triangles) cap(from, to engo.Point, hw float32, lineCap LineCap) {
+	d := direction(from, to)
+	nrm := engo.Point{X: -d.Y, Y: d.X}
+	switch lineCap {
+	case LINE_CAP_ROUND:
+		t.fan(to, hw, math.Atan2(nrm.Y, nrm.X), -math.Pi)
+	case LINE_CAP_SQUARE:
+		end := offset(to, d, hw)
+		t.quad(offset(to, nrm, hw), offset(end, nrm, hw), offset(end, nrm, -hw), offset(to, nrm, -hw))
+	}
+}
@@ -0,0 +1,178 @@
+package engoutil
+
+import (
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/gl"
+	"github.com/EngoEngine/math"
+)
+
+// Shadow is a drop shadow, or an outer glow without offset
+type Shadow struct {
+	DX, DY float32
+	// Blur is the radius of the Gaussian blur, the standard deviation is half of it
+	Blur float32
+	// Spread grows the shadow before the blur.
+	// Text has no spread, it strengthens the blurred glyphs instead.
+	Spread float32
+	Color  *Color
+}
+
+const shadowKinds = SHAPE_KIND_RECT | SHAPE_KIND_CIRCLE | SHAPE_KIND_POLYGON | SHAPE_KIND_TEXT
+
+// (*Shape) SetShadow
+// the shadow is drawn by the Canvas just below the shape, blurred by blur pixels
+func (s *Shape) SetShadow(dx, dy, blur float32, clr uint32) {
+	if !s.requireKind(shadowKinds, "SetShadow") {
+		return
+	}
+	s.setShadow(Shadow{DX: dx, DY: dy, Blur: blur, Color: NewColor(clr)})
+}
+
+// (*Shape) SetGlow an outer glow, the shadow grows by spread around the shape
+func (s *Shape) SetGlow(blur, spread float32, clr uint32) {
+	if !s.requireKind(shadowKinds, "SetGlow") {
+		return
+	}
+	s.setShadow(Shadow{Blur: blur, Spread: spread, Color: NewColor(clr)})
+}
+
+// (*Shape) RemoveShadow removes the shadow or the glow
+func (s *Shape) RemoveShadow() {
+	if t, ok := s.Render.Drawable.(*Text); ok {
+		t.Shadow = nil
+	} else if s.shadow != nil {
+		s.shadow.Render.Drawable.(*shapeCaster).Color = nil
+	}
+}
+
+func (s *Shape) setShadow(shadow Shadow) {
+	if t, ok := s.Render.Drawable.(*Text); ok {
+		t.Shadow = &shadow
+		return
+	}
+	if s.shadow == nil {
+		// the companion shares the space of the shape, and follows it
+		s.shadow = newShape(s.kind)
+		s.shadow.Space = s.Space
+		switch s.Render.Shader() {
+		case common.LegacyShader, ShapeShader, ImageShader, TextShader:
+			s.shadow.Render.SetShader(ShadowShader)
+		default:
+			s.shadow.Render.SetShader(ShadowHUDShader)
+		}
+		s.shadow.Render.Drawable = &shapeCaster{owner: s}
+	}
+	s.shadow.Render.Drawable.(*shapeCaster).Shadow = shadow
+}
+
+var _ common.Drawable = (*shapeCaster)(nil)
+
+// shapeCaster is the drawable of the shadow of a rect, a circle or a polygon
+type shapeCaster struct {
+	Shadow
+	owner *Shape
+
+	// the edges of the polygon, x0, y0, x1, y1, until the points or the size change
+	polygon struct {
+		points   []engo.Point
+		w, h     float32
+		edges    []float32
+		min, max engo.Point
+	}
+}
+
+func (*shapeCaster) Texture() *gl.Texture                       { return nil }
+func (*shapeCaster) Width() float32                             { return 0 }
+func (*shapeCaster) Height() float32                            { return 0 }
+func (*shapeCaster) View() (float32, float32, float32, float32) { return 0, 0, 1, 1 }
+func (*shapeCaster) Close()                                     {}
+
+// sigma the standard deviation, at least half a pixel to smooth the edges of a sharp shadow
+func (c *shapeCaster) sigma() float32 {
+	return math.Max(c.Blur/2, 0.5)
+}
+
+// box the rounded box of the shadow, relative to the position of the shape, without the offset
+func (c *shapeCaster) box() (x0, y0, x1, y1, corner float32) {
+	space := c.owner.Space
+	x1, y1 = space.Width, space.Height
+	switch c.owner.kind {
+	case SHAPE_KIND_RECT:
+		if _, ok := c.owner.Render.Drawable.(*Outline); ok {
+			corner = c.owner.attr[4]
+		}
+	case SHAPE_KIND_CIRCLE:
+		corner = space.Width / 2
+	case SHAPE_KIND_POLYGON:
+		c.updatePolygon()
+		x0, y0, x1, y1 = c.polygon.min.X, c.polygon.min.Y, c.polygon.max.X, c.polygon.max.Y
+	}
+	x0, y0, x1, y1 = x0-c.Spread, y0-c.Spread, x1+c.Spread, y1+c.Spread
+	if corner > 0 {
+		corner += c.Spread
+	}
+	corner = math.Min(math.Max(corner, 0), math.Min(x1-x0, y1-y0)/2)
+	return
+}
+
+// bounds the quad covering the blur, with the offset
+func (c *shapeCaster) bounds() (x0, y0, x1, y1 float32) {
+	x0, y0, x1, y1, _ = c.box()
+	e := 3*c.sigma() + 1
+	return x0 - e + c.DX, y0 - e + c.DY, x1 + e + c.DX, y1 + e + c.DY
+}
+
+// polygonEdges nil for the other shapes, and the polygons with too many edges
+func (c *shapeCaster) polygonEdges() []float32 {
+	if c.owner.kind != SHAPE_KIND_POLYGON {
+		return nil
+	}
+	c.updatePolygon()
+	return c.polygon.edges
+}
+
+func (c *shapeCaster) updatePolygon() {
+	var points []engo.Point
+	switch t := c.owner.Render.Drawable.(type) {
+	case common.ComplexTriangles:
+		points = t.Points
+	case *Outline:
+		points = t.Points
+	}
+	w, h := c.owner.Space.Width, c.owner.Space.Height
+	p := &c.polygon
+	if p.w == w && p.h == h && len(p.points) == len(points) && (len(points) == 0 || &p.points[0] == &points[0]) {
+		return
+	}
+	p.points, p.w, p.h, p.edges = points, w, h, nil
+	p.min = engo.Point{X: math.MaxFloat32, Y: math.MaxFloat32}
+	p.max = engo.Point{X: -math.MaxFloat32, Y: -math.MaxFloat32}
+
+	scaled := make([]engo.Point, len(points))
+	for i, v := range points {
+		scaled[i] = engo.Point{X: v.X * w, Y: v.Y * h}
+		p.min.X, p.min.Y = math.Min(p.min.X, scaled[i].X), math.Min(p.min.Y, scaled[i].Y)
+		p.max.X, p.max.Y = math.Max(p.max.X, scaled[i].X), math.Max(p.max.Y, scaled[i].Y)
+	}
+	if len(points) == 0 {
+		p.min, p.max = engo.Point{}, engo.Point{}
+		return
+	}
+	var contours [][]engo.Point
+	if o, ok := c.owner.Render.Drawable.(*Outline); ok && o.Contours != nil {
+		contours = o.contours(w, h)
+	} else {
+		contours = triangleBoundary(scaled)
+	}
+	for _, contour := range contours {
+		for i, a := range contour {
+			b := contour[(i+1)%len(contour)]
+			p.edges = append(p.edges, a.X, a.Y, b.X, b.Y)
+		}
+	}
+	if len(p.edges)/4 > maxShadowEdges {
+		warning("(Shape) SetShadow(), %d edges exceeds the limit of %d, the shadow of the bounds is drawn", len(p.edges)/4, maxShadowEdges)
+		p.edges = nil
+	}
+}
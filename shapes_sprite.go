@@ -0,0 +1,236 @@
+package engoutil
+
+import (
+	"github.com/EngoEngine/engo"
+)
+
+type AnimationMode uint8
+
+const (
+	ANIMATION_LOOP AnimationMode = iota
+	// ANIMATION_PING_PONG plays forward, then backward
+	ANIMATION_PING_PONG
+	// ANIMATION_ONCE stops on the last frame
+	ANIMATION_ONCE
+)
+
+// Frame is a region of the sprite sheet, shown for Duration seconds
+type Frame struct {
+	Region   engo.AABB
+	Duration float32
+}
+
+// SpriteSheet is a texture split into cells, shared by the sprites
+type SpriteSheet struct {
+	image *Image
+	Cells []engo.AABB
+}
+
+// NewSpriteSheet splits the source into a grid of cells, from left to right, top to bottom.
+// source is an `engo.Files` URL, an image.Image, a common.Texture or a *common.Texture.
+func NewSpriteSheet(source interface{}, cellWidth, cellHeight float32) *SpriteSheet {
+	sheet := NewPackedSpriteSheet(source, nil)
+	if cellWidth <= 0 || cellHeight <= 0 {
+		return sheet
+	}
+	region := sheet.image.Region
+	for y := region.Min.Y; y+cellHeight <= region.Max.Y; y += cellHeight {
+		for x := region.Min.X; x+cellWidth <= region.Max.X; x += cellWidth {
+			sheet.Cells = append(sheet.Cells, engo.AABB{
+				Min: engo.Point{X: x, Y: y},
+				Max: engo.Point{X: x + cellWidth, Y: y + cellHeight},
+			})
+		}
+	}
+	return sheet
+}
+
+// NewPackedSpriteSheet the cells are regions of the source in pixels, e.g. from a texture packer
+func NewPackedSpriteSheet(source interface{}, cells []engo.AABB) *SpriteSheet {
+	img, err := newImageSource(source)
+	if err != nil {
+		warning("NewSpriteSheet(). Error was: %s", err.Error())
+		img = &Image{}
+	}
+	return &SpriteSheet{image: img, Cells: cells}
+}
+
+// (*SpriteSheet) Frames returns the frames of the cells, with the same duration
+func (sheet *SpriteSheet) Frames(duration float32, cells ...int) []Frame {
+	frames := make([]Frame, 0, len(cells))
+	for _, i := range cells {
+		if i < 0 || i >= len(sheet.Cells) {
+			warning("(SpriteSheet) Frames(), cell %d out of range", i)
+			continue
+		}
+		frames = append(frames, Frame{Region: sheet.Cells[i], Duration: duration})
+	}
+	return frames
+}
+
+// (*SpriteSheet) Close deletes the texture, the sprites of the sheet can not be drawn anymore
+func (sheet *SpriteSheet) Close() {
+	sheet.image.Close()
+}
+
+type animation struct {
+	frames  []Frame
+	mode    AnimationMode
+	index   int
+	step    int
+	elapsed float32
+	playing bool
+}
+
+// NewSprite
+// zero width or height uses the size of the first frame.
+func NewSprite(x, y, width, height float32, sheet *SpriteSheet, frames []Frame, mode AnimationMode) *Shape {
+	s := newShape(SHAPE_KIND_SPRITE)
+	// the texture belongs to the sheet
+	img := *sheet.image
+	img.owned = false
+	s.Render.Drawable = &img
+	s.Render.Color = NewColor(0xFFFFFFFF)
+	s.Render.SetShader(ImageHUDShader)
+	s.animation = &animation{}
+	s.SetAnimation(frames, mode)
+	if width == 0 {
+		width = img.Width()
+	}
+	if height == 0 {
+		height = img.Height()
+	}
+	s.Transform(x, y, width, height)
+	return s
+}
+
+// (*Shape) SetAnimation replaces the frames, and plays from the first one
+func (s *Shape) SetAnimation(frames []Frame, mode AnimationMode) {
+	if !s.requireKind(SHAPE_KIND_SPRITE, "SetAnimation") {
+		return
+	}
+	*s.animation = animation{frames: frames, mode: mode, step: 1, playing: true}
+	s.updateSprite()
+}
+
+// (*Shape) OnAnimationEnd
+// called when the last frame is done, every cycle for ANIMATION_LOOP and ANIMATION_PING_PONG.
+func (s *Shape) OnAnimationEnd(fn func(*Shape)) {
+	s.onAnimationEnd = fn
+}
+
+// (*Shape) Play continues the animation, or restarts it after ANIMATION_ONCE has ended
+func (s *Shape) Play() {
+	if !s.requireKind(SHAPE_KIND_SPRITE, "Play") {
+		return
+	}
+	a := s.animation
+	if a.mode == ANIMATION_ONCE && !a.playing && a.index == len(a.frames)-1 {
+		a.index = 0
+		a.elapsed = 0
+		s.updateSprite()
+	}
+	a.playing = true
+}
+
+// (*Shape) Pause
+func (s *Shape) Pause() {
+	if s.requireKind(SHAPE_KIND_SPRITE, "Pause") {
+		s.animation.playing = false
+	}
+}
+
+// (*Shape) Playing
+func (s *Shape) Playing() bool {
+	return s.animation != nil && s.animation.playing
+}
+
+// (*Shape) Seek jumps to the frame
+func (s *Shape) Seek(frame int) {
+	if !s.requireKind(SHAPE_KIND_SPRITE, "Seek") {
+		return
+	}
+	a := s.animation
+	if frame < 0 || frame >= len(a.frames) {
+		warning("(Shape) Seek(), frame %d out of range", frame)
+		return
+	}
+	a.index = frame
+	a.elapsed = 0
+	s.updateSprite()
+}
+
+// (*Shape) Frame returns the index of the current frame
+func (s *Shape) Frame() int {
+	if s.animation == nil {
+		return 0
+	}
+	return s.animation.index
+}
+
+// updateAnimation is called by the Canvas every frame
+func (s *Shape) updateAnimation(dt float32) {
+	a := s.animation
+	if !a.playing || len(a.frames) == 0 {
+		return
+	}
+	index, ended := a.index, false
+	a.elapsed += dt
+	// frames without duration are skipped, but never more than a whole cycle in one update
+	for steps := 0; a.elapsed >= a.frames[a.index].Duration && steps <= 2*len(a.frames); steps++ {
+		a.elapsed -= a.frames[a.index].Duration
+		if a.advance() {
+			ended = true
+			if !a.playing {
+				a.elapsed = 0
+				break
+			}
+		}
+	}
+	if a.index != index {
+		s.updateSprite()
+	}
+	if ended && s.onAnimationEnd != nil {
+		s.onAnimationEnd(s)
+	}
+}
+
+// advance moves to the next frame, returns true when a cycle is done
+func (a *animation) advance() (ended bool) {
+	n := len(a.frames)
+	next := a.index + a.step
+	switch a.mode {
+	case ANIMATION_LOOP:
+		if next >= n {
+			next = 0
+			ended = true
+		}
+	case ANIMATION_PING_PONG:
+		if next >= n || next < 0 {
+			a.step = -a.step
+			next = a.index + a.step
+			if next < 0 || next >= n {
+				next = 0
+			}
+		}
+		// back to the first frame
+		ended = next == 0 && a.step < 0
+		if ended {
+			a.step = 1
+		}
+	case ANIMATION_ONCE:
+		if next >= n {
+			a.playing = false
+			return true
+		}
+	}
+	a.index = next
+	return
+}
+
+func (s *Shape) updateSprite() {
+	a := s.animation
+	if img, ok := s.Render.Drawable.(*Image); ok && a.index < len(a.frames) {
+		img.Region = a.frames[a.index].Region
+	}
+}
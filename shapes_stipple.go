@@ -1,5 +1,10 @@
 package engoutil
 
+import (
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/math"
+)
+
 // NewStippleLine
 func NewStippleLine(points Points, factor int32, pattern uint16, lineWidth float32, clr uint32) *Shape {
 	s := newShape(SHAPE_KIND_STIPPLE_LINE)
@@ -10,6 +15,7 @@ func NewStippleLine(points Points, factor int32, pattern uint16, lineWidth float
 	s.Render.Drawable = StippleLine{BorderWidth: lineWidth, Points: points.Points(), Stipple: *s.stipple}
 	s.Render.Color = NewColor(clr)
 	s.Render.SetShader(ShapeHUDShader)
+	s.updateStippleVertices()
 	return s
 }
 
@@ -31,9 +37,15 @@ func NewStippleRect(x, y, width, height float32, factor int32, pattern uint16, l
 	s.Space.Position.Y = y
 	s.Space.Width = width
 	s.Space.Height = height
+	s.updateStippleVertices()
 	return s
 }
 
+// stippleKinds are the shapes with a dashed stroke.
+// circles, polygons, curves and rects are drawn by the shape shader after SetStipple.
+const stippleKinds = SHAPE_KIND_STIPPLE_LINE | SHAPE_KIND_STIPPLE_RECT | SHAPE_KIND_ARROW |
+	SHAPE_KIND_CIRCLE | SHAPE_KIND_POLYGON | SHAPE_KIND_CURVE | SHAPE_KIND_RECT
+
 // (*Shape) Stipple
 func (s *Shape) Stipple() (int32, uint16) {
 	if s.stipple == nil {
@@ -43,30 +55,85 @@ func (s *Shape) Stipple() (int32, uint16) {
 }
 
 // (*Shape) SetStipple
+// every bit of the pattern, starting from the lowest one, covers factor pixels
 func (s *Shape) SetStipple(factor int32, pattern uint16) {
-	if !s.requireKind(SHAPE_KIND_STIPPLE_LINE|SHAPE_KIND_STIPPLE_RECT, "SetStipple") {
+	if !s.requireKind(stippleKinds, "SetStipple") {
+		return
+	}
+	s.initStipple()
+	if s.stipple.Factor == factor && s.stipple.Pattern == pattern && len(s.stipple.Dashes) == 0 {
+		return
+	}
+	s.stipple.Factor = factor
+	s.stipple.Pattern = pattern
+	s.stipple.Dashes = nil
+	s.updateStipple()
+}
+
+// (*Shape) SetDashes
+// the lengths of the dashes and the gaps in pixels, they replace the pattern of SetStipple.
+// an odd number of values is repeated, at most 16 values.
+func (s *Shape) SetDashes(dashes ...float32) {
+	if !s.requireKind(stippleKinds, "SetDashes") {
 		return
 	}
-	if s.stipple.Factor == factor && s.stipple.Pattern == pattern {
+	s.initStipple()
+	s.stipple.Dashes = append([]float32(nil), dashes...)
+	s.updateStipple()
+}
+
+// (*Shape) SetDashOffset moves the pattern backward along the line, in pixels
+func (s *Shape) SetDashOffset(offset float32) {
+	if !s.requireKind(stippleKinds, "SetDashOffset") {
 		return
 	}
+	s.initStipple()
+	if s.stipple.Offset != offset {
+		s.stipple.Offset = offset
+		s.updateStipple()
+	}
+}
+
+// (*Shape) DashOffset
+func (s *Shape) DashOffset() float32 {
+	if s.stipple == nil {
+		return 0
+	}
+	return s.stipple.Offset
+}
+
+// initStipple the shapes of the legacy shader start solid
+func (s *Shape) initStipple() {
+	if s.stipple == nil {
+		s.stipple = &Stipple{Factor: 1, Pattern: 0xFFFF}
+		s.toOutline()
+	}
+}
+
+func (s *Shape) updateStipple() {
 	switch t := s.Render.Drawable.(type) {
+	case *Outline:
+		t.Stipple = *s.stipple
 	case StippleLine:
-		t.Stipple.Factor = factor
-		t.Stipple.Pattern = pattern
+		t.Stipple = *s.stipple
 		s.Render.Drawable = t
 	case StippleRect:
-		t.Stipple.Factor = factor
-		t.Stipple.Pattern = pattern
+		t.Stipple = *s.stipple
 		s.Render.Drawable = t
+	case *Arrow:
+		t.Stipple = *s.stipple
+		s.updateArrow(t)
 	}
-	s.stipple.Factor = factor
-	s.stipple.Pattern = pattern
 }
 
 // (*Shape) MoveStippleLeft
+// the dashes of SetDashes move 1 pixel forward
 func (s *Shape) MoveStippleLeft() {
-	if !s.requireKind(SHAPE_KIND_STIPPLE_LINE|SHAPE_KIND_STIPPLE_RECT, "MoveStippleLeft") {
+	if !s.requireKind(stippleKinds, "MoveStippleLeft") || s.stipple == nil {
+		return
+	}
+	if len(s.stipple.Dashes) > 0 {
+		s.SetDashOffset(s.stipple.Offset - 1)
 		return
 	}
 	if s.stipple.Pattern == 0xFFFF {
@@ -82,8 +149,13 @@ func (s *Shape) MoveStippleLeft() {
 }
 
 // (*Shape) MoveStippleRight
+// the dashes of SetDashes move 1 pixel backward
 func (s *Shape) MoveStippleRight() {
-	if !s.requireKind(SHAPE_KIND_STIPPLE_LINE|SHAPE_KIND_STIPPLE_RECT, "MoveStippleRight") {
+	if !s.requireKind(stippleKinds, "MoveStippleRight") || s.stipple == nil {
+		return
+	}
+	if len(s.stipple.Dashes) > 0 {
+		s.SetDashOffset(s.stipple.Offset + 1)
 		return
 	}
 	if s.stipple.Pattern == 0xFFFF {
@@ -97,3 +169,81 @@ func (s *Shape) MoveStippleRight() {
 	}
 	s.SetStipple(s.stipple.Factor, pattern)
 }
+
+// updateStippleVertices tessellates the stroke after the points, the size or the width changed
+func (s *Shape) updateStippleVertices() {
+	switch t := s.Render.Drawable.(type) {
+	case StippleLine:
+		t.vertices = strokeStippleLine(t.Points, t.BorderWidth)
+		s.Render.Drawable = t
+	case StippleRect:
+		t.vertices = strokeStippleRect(s.Space.Width, s.Space.Height, t.BorderWidth)
+		s.Render.Drawable = t
+	}
+}
+
+// strokeStippleLine tessellates every two points as a segment,
+// the segments starting where the previous one ends are joined into one path.
+func strokeStippleLine(points []engo.Point, width float32) (vertices []float32) {
+	var path []engo.Point
+	flush := func() {
+		closed := len(path) > 2 && path[0] == path[len(path)-1]
+		vertices = append(vertices, strokeDashes(path, width, LINE_JOIN_MITER, LINE_CAP_BUTT, closed)...)
+		path = nil
+	}
+	for i := 0; i+1 < len(points); i += 2 {
+		if len(path) == 0 || path[len(path)-1] != points[i] {
+			flush()
+			path = append(path, points[i])
+		}
+		path = append(path, points[i+1])
+	}
+	flush()
+	return
+}
+
+// strokeStippleRect the stroke is centered on the edges, clockwise from the top left corner
+func strokeStippleRect(width, height, lineWidth float32) []float32 {
+	corners := []engo.Point{{X: 0, Y: 0}, {X: width, Y: 0}, {X: width, Y: height}, {X: 0, Y: height}}
+	return strokeDashes(corners, lineWidth, LINE_JOIN_MITER, LINE_CAP_BUTT, true)
+}
+
+// strokeDashes tessellates the points as strokePolyline,
+// every vertex is followed by its distance along the points, for the dashes of the shader.
+func strokeDashes(points []engo.Point, width float32, join LineJoin, lineCap LineCap, closed bool) (vertices []float32) {
+	unique, closed := uniquePoints(points, closed)
+	n := len(unique)
+	if n < 2 || width <= 0 {
+		return nil
+	}
+	var (
+		hw       = width / 2
+		segments = n - 1
+		distance float32
+	)
+	if closed {
+		segments = n
+	}
+	for i := 0; i < segments; i++ {
+		a, b := unique[i], unique[(i+1)%n]
+		d := direction(a, b)
+		nrm := engo.Point{X: -d.Y, Y: d.X}
+		var t triangles
+		t.quad(offset(a, nrm, hw), offset(b, nrm, hw), offset(b, nrm, -hw), offset(a, nrm, -hw))
+		if i > 0 || closed {
+			t.join(unique[(i-1+n)%n], a, b, hw, join)
+		}
+		if !closed && i == 0 {
+			t.cap(b, a, hw, lineCap)
+		}
+		if !closed && i == segments-1 {
+			t.cap(a, b, hw, lineCap)
+		}
+		// the distance is the projection on the segment, caps go below 0 and beyond the length
+		for j := 0; j < len(t); j += 2 {
+			vertices = append(vertices, t[j], t[j+1], distance+(t[j]-a.X)*d.X+(t[j+1]-a.Y)*d.Y)
+		}
+		distance += math.Hypot(b.X-a.X, b.Y-a.Y)
+	}
+	return
+}
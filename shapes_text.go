@@ -5,14 +5,30 @@ import (
 )
 
 type TextStyle struct {
-	URL           string
+	URL string
+	// the box of the text, the lines are wrapped at the width and aligned inside the box.
+	// zero is the size of the lines.
 	Width         float32
 	Height        float32
+	Align         TextAlign
+	VAlign        TextVAlign
 	BG            uint32
 	BgStyle       uint8
 	LineSpacing   float32
 	LetterSpacing float32
 	Padding       Padding
+	// Kerning kerns the pairs of characters, see PreloadFont
+	Kerning bool
+	// Fallbacks the urls of the fonts of the characters missing from the font, see SetFallbackFonts
+	Fallbacks []string
+	// the shadow of the characters, nil without shadow. the Spread strengthens the blurred characters
+	Shadow *Shadow
+	// the outline around the characters, in pixels
+	OutlineWidth float32
+	OutlineColor uint32
+	// SDF draws the text from a signed distance field atlas, it stays crisp when scaled or rotated,
+	// one atlas serves all the sizes of the font. see Font.SDF
+	SDF bool
 }
 
 var defaultFontURL = FontDroidSans
@@ -41,6 +57,7 @@ func (s *Shape) SetText(text string) {
 	}
 	if t, ok := s.Render.Drawable.(*Text); ok {
 		t.Text = text
+		t.Spans = nil
 		if s.attr[2] != 0 {
 			s.SetAnchor(s.attr[2], s.attr[3])
 		}
@@ -75,6 +92,57 @@ func (s *Shape) SetBgStyle(style uint8) {
 	}
 }
 
+// (*Shape) SetTextBox 设置文本框的大小, 文本在宽度处换行, 0 为文本的大小
+func (s *Shape) SetTextBox(width, height float32) {
+	if !s.requireKind(SHAPE_KIND_TEXT, "SetTextBox") {
+		return
+	}
+	if s.Render == nil || s.Render.Drawable == nil {
+		return
+	}
+	if t, ok := s.Render.Drawable.(*Text); ok {
+		t.width, t.height = width, height
+		s.SetAnchor(s.attr[2], s.attr[3])
+	}
+}
+
+// (*Shape) SetTextAlign 设置文本在文本框内的对齐方式
+func (s *Shape) SetTextAlign(align TextAlign, valign TextVAlign) {
+	if !s.requireKind(SHAPE_KIND_TEXT, "SetTextAlign") {
+		return
+	}
+	if s.Render == nil || s.Render.Drawable == nil {
+		return
+	}
+	if t, ok := s.Render.Drawable.(*Text); ok {
+		t.Align, t.VAlign = align, valign
+	}
+}
+
+// (*Shape) SetTextShadow 设置文字阴影, same as SetShadow
+// the shadow is drawn behind the characters, blurred up to 8 pixels of the font texture
+func (s *Shape) SetTextShadow(dx, dy, blur float32, clr uint32) {
+	if !s.requireKind(SHAPE_KIND_TEXT, "SetTextShadow") {
+		return
+	}
+	s.setShadow(Shadow{DX: dx, DY: dy, Blur: blur, Color: NewColor(clr)})
+}
+
+// (*Shape) SetTextOutline 设置文字描边, 0 宽度去除描边
+// the outline is drawn behind the characters, up to 8 pixels of the font texture
+func (s *Shape) SetTextOutline(width float32, clr uint32) {
+	if !s.requireKind(SHAPE_KIND_TEXT, "SetTextOutline") {
+		return
+	}
+	if s.Render == nil || s.Render.Drawable == nil {
+		return
+	}
+	if t, ok := s.Render.Drawable.(*Text); ok {
+		t.OutlineWidth = width
+		t.OutlineColor = NewColor(clr)
+	}
+}
+
 func (s *Shape) SetLetterSpacing(spacing float32) {
 	if !s.requireKind(SHAPE_KIND_TEXT, "SetLetterSpacing") {
 		return
@@ -88,6 +156,20 @@ func (s *Shape) SetLetterSpacing(spacing float32) {
 	}
 }
 
+// (*Shape) SetKerning 设置字距调整
+func (s *Shape) SetKerning(kerning bool) {
+	if !s.requireKind(SHAPE_KIND_TEXT, "SetKerning") {
+		return
+	}
+	if s.Render == nil || s.Render.Drawable == nil {
+		return
+	}
+	if t, ok := s.Render.Drawable.(*Text); ok {
+		t.Kerning = kerning
+		s.SetAnchor(s.attr[2], s.attr[3])
+	}
+}
+
 func (s *Shape) SetLineSpacing(spacing float32) {
 	if !s.requireKind(SHAPE_KIND_TEXT, "SetLineSpacing") {
 		return
@@ -118,6 +200,7 @@ func newText(text string, x, y, ax, ay, size float32, color uint32, style *TextS
 	f := &Font{
 		URL:   style.URL,
 		Size:  float64(size),
+		SDF:   style.SDF,
 		scale: s.attr[4],
 	}
 
@@ -130,13 +213,23 @@ func newText(text string, x, y, ax, ay, size float32, color uint32, style *TextS
 		Text:          text,
 		LineSpacing:   style.LineSpacing,
 		LetterSpacing: style.LetterSpacing,
+		Kerning:       style.Kerning,
+		Fallbacks:     append([]string(nil), style.Fallbacks...),
 		Position:      engo.Point{X: x, Y: y},
 		Color:         NewColor(color),
 		BgStyle:       style.BgStyle,
+		Align:         style.Align,
+		VAlign:        style.VAlign,
+		OutlineWidth:  style.OutlineWidth,
+		OutlineColor:  NewColor(style.OutlineColor),
 		Padding:       style.Padding,
 		width:         style.Width,
 		height:        style.Height,
 	}
+	if style.Shadow != nil {
+		shadow := *style.Shadow
+		t.Shadow = &shadow
+	}
 	s.Render.Drawable = t
 	s.Render.Color = NewColor(style.BG)
 	s.Render.Scale.X = 1 / s.attr[4]
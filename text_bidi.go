@@ -0,0 +1,236 @@
+package engoutil
+
+import (
+	"golang.org/x/text/unicode/bidi"
+)
+
+// the deepest embedding level of the explicit embeddings and isolates
+const bidiMaxDepth = 125
+
+func bidiClass(char rune) bidi.Class {
+	p, _ := bidi.LookupRune(char)
+	return p.Class()
+}
+
+// bidiLevels resolves the embedding levels of the characters of the paragraph in logical order, and the level of
+// the paragraph. the bidi package resolves the directions of the characters, with the explicit embeddings, overrides
+// and isolates, the levels are the explicit levels raised to the directions, the numbers of the left-to-right levels
+// are raised by two
+func bidiLevels(paragraph []styledRune) (base uint8) {
+	n := len(paragraph)
+	if n == 0 {
+		return
+	}
+	classes := make([]bidi.Class, n)
+	text := make([]rune, n)
+	for i, sr := range paragraph {
+		classes[i], text[i] = bidiClass(sr.char), sr.char
+		if classes[i] == bidi.B {
+			// a paragraph separator would end the paragraph of the bidi package, it is on the level of the paragraph
+			text[i] = ' '
+		}
+	}
+	// P2, P3
+	base, _ = firstStrong(classes, 0)
+
+	var opts []bidi.Option
+	if base == 1 {
+		opts = append(opts, bidi.DefaultDirection(bidi.RightToLeft))
+	}
+	var p bidi.Paragraph
+	rtl := make([]bool, n)
+	if _, err := p.SetString(string(text), opts...); err == nil {
+		if order, err := p.Order(); err == nil {
+			for i := 0; i < order.NumRuns(); i++ {
+				run := order.Run(i)
+				start, end := run.Pos()
+				for j := start; j <= end && j < n; j++ {
+					rtl[j] = run.Direction() == bidi.RightToLeft
+				}
+			}
+		}
+	}
+
+	// I1, I2, the European numbers after a left-to-right character are left-to-right (W7)
+	explicit := explicitLevels(classes, base)
+	strong := bidi.L
+	if base == 1 {
+		strong = bidi.R
+	}
+	for i, c := range classes {
+		level := explicit[i]
+		switch {
+		case rtl[i] != (level%2 == 1):
+			level++
+		case !rtl[i] && (c == bidi.AN || c == bidi.EN && strong != bidi.L):
+			level += 2
+		}
+		if c == bidi.L || c == bidi.R || c == bidi.AL {
+			strong = c
+		}
+		paragraph[i].level = level
+	}
+	// the separators and the terminators of the numbers (W4, W5), and the marks (W1)
+	for i, c := range classes {
+		switch {
+		case c == bidi.NSM && i > 0 && explicit[i-1] == explicit[i]:
+			paragraph[i].level = paragraph[i-1].level
+		case (c == bidi.ES || c == bidi.CS || c == bidi.ET) && paragraph[i].level == explicit[i] && explicit[i]%2 == 0:
+			number := explicit[i] + 2
+			end := i
+			for end < n && (classes[end] == bidi.ES || classes[end] == bidi.CS || classes[end] == bidi.ET) {
+				end++
+			}
+			before := i > 0 && paragraph[i-1].level == number
+			after := end < n && paragraph[end].level == number
+			if before && after || c == bidi.ET && (before || after) {
+				paragraph[i].level = number
+			}
+		}
+	}
+	for i, c := range classes {
+		if c == bidi.B {
+			paragraph[i].level = base
+		}
+	}
+	return
+}
+
+// firstStrong the level of the first strong character from i, the isolates are skipped,
+// up to the PDI closing the isolate of i. ok is false without one
+func firstStrong(classes []bidi.Class, i int) (level uint8, ok bool) {
+	depth := 0
+	for ; i < len(classes); i++ {
+		switch classes[i] {
+		case bidi.L:
+			if depth == 0 {
+				return 0, true
+			}
+		case bidi.R, bidi.AL:
+			if depth == 0 {
+				return 1, true
+			}
+		case bidi.LRI, bidi.RLI, bidi.FSI:
+			depth++
+		case bidi.PDI:
+			if depth == 0 {
+				return 0, false
+			}
+			depth--
+		case bidi.B:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// explicitLevels the levels of the explicit embeddings, overrides and isolates (X1-X8)
+func explicitLevels(classes []bidi.Class, base uint8) []uint8 {
+	type entry struct {
+		level   uint8
+		isolate bool
+	}
+	var (
+		stack                                               = []entry{{level: base}}
+		overflowIsolates, overflowEmbeddings, validIsolates int
+		levels                                              = make([]uint8, len(classes))
+	)
+	for i, c := range classes {
+		top := stack[len(stack)-1]
+		levels[i] = top.level
+		switch c {
+		case bidi.RLE, bidi.RLO, bidi.RLI, bidi.LRE, bidi.LRO, bidi.LRI, bidi.FSI:
+			isolate := c == bidi.RLI || c == bidi.LRI || c == bidi.FSI
+			rtl := c == bidi.RLE || c == bidi.RLO || c == bidi.RLI
+			if c == bidi.FSI {
+				level, _ := firstStrong(classes, i+1)
+				rtl = level == 1
+			}
+			// the least odd or even level above the top
+			next := (top.level + 2) &^ 1
+			if rtl {
+				next = (top.level + 1) | 1
+			}
+			switch {
+			case next <= bidiMaxDepth && overflowIsolates == 0 && overflowEmbeddings == 0:
+				if isolate {
+					validIsolates++
+				}
+				stack = append(stack, entry{next, isolate})
+			case isolate:
+				overflowIsolates++
+			case overflowIsolates == 0:
+				overflowEmbeddings++
+			}
+		case bidi.PDI:
+			switch {
+			case overflowIsolates > 0:
+				overflowIsolates--
+			case validIsolates > 0:
+				overflowEmbeddings = 0
+				for !stack[len(stack)-1].isolate {
+					stack = stack[:len(stack)-1]
+				}
+				stack = stack[:len(stack)-1]
+				validIsolates--
+			}
+			levels[i] = stack[len(stack)-1].level
+		case bidi.PDF:
+			switch {
+			case overflowIsolates > 0:
+			case overflowEmbeddings > 0:
+				overflowEmbeddings--
+			case !top.isolate && len(stack) > 1:
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return levels
+}
+
+// bidiReorder the clusters of a line in visual order. the separators, and the whitespace and the isolates
+// before them and at the end of the line, are on the level of the paragraph (L1), the runs are reversed (L2).
+// the glyphs of the clusters are in visual order already
+func bidiReorder(line []cluster, base uint8) []cluster {
+	visual := append(make([]cluster, 0, len(line)), line...)
+	trailing := true
+	for i := len(visual) - 1; i >= 0; i-- {
+		switch bidiClass(visual[i].char) {
+		case bidi.S, bidi.B:
+			visual[i].level, trailing = base, true
+		case bidi.WS, bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI:
+			if trailing {
+				visual[i].level = base
+			}
+		default:
+			trailing = false
+		}
+	}
+
+	var highest, lowestOdd uint8 = 0, 0xff
+	for _, c := range visual {
+		if c.level > highest {
+			highest = c.level
+		}
+		if c.level%2 == 1 && c.level < lowestOdd {
+			lowestOdd = c.level
+		}
+	}
+	for level := highest; level >= lowestOdd && level > 0; level-- {
+		for i := 0; i < len(visual); {
+			if visual[i].level < level {
+				i++
+				continue
+			}
+			end := i
+			for end < len(visual) && visual[end].level >= level {
+				end++
+			}
+			for a, b := i, end-1; a < b; a, b = a+1, b-1 {
+				visual[a], visual[b] = visual[b], visual[a]
+			}
+			i = end
+		}
+	}
+	return visual
+}
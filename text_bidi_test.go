@@ -0,0 +1,55 @@
+package engoutil
+
+import "testing"
+
+func TestBidi(t *testing.T) {
+	for _, c := range []struct {
+		text   string
+		base   uint8
+		levels string
+		// the characters from left to right, the mirrored brackets are glyphs of the shaping
+		visual string
+	}{
+		{"abc", 0, "000", "abc"},
+		{"אבג", 1, "111", "גבא"},
+		{"abc אבג def", 0, "00001110000", "abc גבא def"},
+		{"abc, אבג.", 0, "000001110", "abc, גבא."},
+		// the numbers are on the level above the right-to-left characters
+		{"אבג 123", 1, "1111222", "123 גבא"},
+		{"12.5% אבג", 1, "222221111", "גבא 12.5%"},
+		{"عدد 12", 1, "111122", "12 ددع"},
+		{"abc 123", 0, "0000000", "abc 123"},
+		{"אבג abc!", 1, "11112221", "!abc גבא"},
+		{"א(ב)ג", 1, "11111", "ג)ב(א"},
+		// the whitespace at the end of the line and the separators are on the level of the paragraph
+		{"אבג abc ", 1, "11112221", " abc גבא"},
+		{"abc\tאבג", 0, "0000111", "abc\tגבא"},
+		// the explicit embeddings and the isolates
+		{"‫ab‬ c", 0, "022200", "‫ab‬ c"},
+		{"a⁧b c⁩ d", 0, "00222000", "a⁧b c⁩ d"},
+		{"a⁧בג⁩ d", 0, "0011000", "a⁧גב⁩ d"},
+	} {
+		paragraph := make([]styledRune, 0, len(c.text))
+		for _, char := range c.text {
+			paragraph = append(paragraph, styledRune{char: char})
+		}
+		base := bidiLevels(paragraph)
+		levels := make([]byte, len(paragraph))
+		line := make([]cluster, len(paragraph))
+		for i, sr := range paragraph {
+			levels[i] = '0' + sr.level
+			line[i] = cluster{start: i, count: 1, char: sr.char, level: sr.level}
+		}
+		if base != c.base || string(levels) != c.levels {
+			t.Errorf("%q: base %d, levels %s, want %d, %s", c.text, base, levels, c.base, c.levels)
+			continue
+		}
+		visual := make([]rune, 0, len(line))
+		for _, cl := range bidiReorder(line, base) {
+			visual = append(visual, cl.char)
+		}
+		if string(visual) != c.visual {
+			t.Errorf("%q: visual %q, want %q", c.text, string(visual), c.visual)
+		}
+	}
+}
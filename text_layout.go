@@ -0,0 +1,334 @@
+package engoutil
+
+import (
+	"unicode"
+
+	"github.com/EngoEngine/math"
+	"github.com/golang/freetype/truetype"
+)
+
+// TextAlign the horizontal alignment of the lines inside the box of the text
+type TextAlign uint8
+
+const (
+	TEXT_ALIGN_LEFT TextAlign = iota
+	TEXT_ALIGN_CENTER
+	TEXT_ALIGN_RIGHT
+	// TEXT_ALIGN_JUSTIFY stretches the spaces of the wrapped lines to the width of the box,
+	// the lines without spaces are stretched between the characters, the last line of a paragraph is left aligned
+	TEXT_ALIGN_JUSTIFY
+)
+
+// TextVAlign the vertical alignment of the lines inside the box of the text, when the box is higher than them
+type TextVAlign uint8
+
+const (
+	TEXT_VALIGN_TOP TextVAlign = iota
+	TEXT_VALIGN_MIDDLE
+	TEXT_VALIGN_BOTTOM
+)
+
+// the soft hyphen is a hyphenation point, it is only drawn as a hyphen at the end of a wrapped line
+const softHyphen = '\u00AD'
+
+// glyph is a glyph placed by the layout, x is the start of the advance of its cluster,
+// y is the top of the line where its font would start it, both with the offset of the glyph.
+// in pixels of the font texture
+type glyph struct {
+	index truetype.Index
+	style *spanStyle
+	x, y  float32
+}
+
+// underline the underline of a cluster, x is the start of its advance, y is the top of its line for its font
+type underline struct {
+	style         *spanStyle
+	x, y, advance float32
+}
+
+// styledRune a character of the text with the style of its span
+type styledRune struct {
+	char  rune
+	style *spanStyle
+	// the bidirectional level, odd for the right-to-left characters
+	level uint8
+}
+
+// textLayout the glyphs of the text placed in their lines, and the size of the box
+type textLayout struct {
+	glyphs        []glyph
+	underlines    []underline
+	width, height float32
+}
+
+// quads the number of quads of the layout, the bold glyphs are drawn twice
+func (l textLayout) quads() int {
+	n := len(l.glyphs) + len(l.underlines)
+	for _, g := range l.glyphs {
+		if g.style.bold {
+			n++
+		}
+	}
+	return n
+}
+
+// layout shapes the paragraphs, wraps them at the width of the box, and aligns the lines inside the box.
+// without a width, the box is the size of the longest line.
+// the lines are as high as their largest font, the glyphs share the baseline of the line.
+func (t *Text) layout() (l textLayout) {
+	scale := t.Font.scale
+	if scale == 0 {
+		scale = 1
+	}
+	var (
+		boxWidth  = t.width * scale
+		boxHeight = t.height * scale
+		base      = t.baseStyle()
+		lines     [][]cluster
+		// the lines ending a paragraph are not justified
+		last []bool
+		// the levels of the paragraphs of the lines
+		levels []uint8
+	)
+
+	var paragraph []styledRune
+	for _, sr := range append(t.styledRunes(base), styledRune{char: '\n', style: base}) {
+		if sr.char != '\n' {
+			paragraph = append(paragraph, sr)
+			continue
+		}
+		level := bidiLevels(paragraph)
+		wrapped := t.wrap(paragraph, t.shape(paragraph), boxWidth)
+		lines = append(lines, wrapped...)
+		for i := range wrapped {
+			last = append(last, i == len(wrapped)-1)
+			levels = append(levels, level)
+		}
+		paragraph = nil
+	}
+
+	var (
+		widths  = make([]float32, len(lines))
+		ascents = make([]float32, len(lines))
+		heights = make([]float32, len(lines))
+	)
+	for i, line := range lines {
+		widths[i] = t.lineWidth(line)
+		if widths[i] > l.width {
+			l.width = widths[i]
+		}
+		var descent float32
+		if len(line) == 0 {
+			ascents[i], descent = base.ascent(), base.descent()
+		}
+		for _, c := range line {
+			ascents[i] = math.Max(ascents[i], c.style.ascent())
+			descent = math.Max(descent, c.style.descent())
+		}
+		heights[i] = ascents[i] + descent
+		if i > 0 {
+			l.height += t.LineSpacing
+		}
+		l.height += heights[i]
+	}
+
+	var currentY float32
+	if boxWidth > 0 {
+		l.width = boxWidth
+	}
+	if boxHeight > 0 {
+		switch t.VAlign {
+		case TEXT_VALIGN_MIDDLE:
+			currentY = (boxHeight - l.height) / 2
+		case TEXT_VALIGN_BOTTOM:
+			currentY = boxHeight - l.height
+		}
+		l.height = boxHeight
+	}
+
+	for i, line := range lines {
+		var (
+			currentX float32
+			extra    = l.width - widths[i]
+			// the space added to the gaps of a justified line
+			gap    float32
+			spaces bool
+		)
+		switch t.Align {
+		case TEXT_ALIGN_CENTER:
+			currentX = extra / 2
+		case TEXT_ALIGN_RIGHT:
+			currentX = extra
+		case TEXT_ALIGN_JUSTIFY:
+			if last[i] || extra <= 0 {
+				break
+			}
+			visible := trimSpaces(line)
+			gaps := 0
+			for _, c := range visible {
+				if unicode.IsSpace(c.char) {
+					gaps++
+				}
+			}
+			if spaces = gaps > 0; !spaces {
+				gaps = len(visible) - 1
+			}
+			if gaps > 0 {
+				gap = extra / float32(gaps)
+			}
+		}
+
+		for _, c := range t.visualLine(line, levels[i]) {
+			y := currentY + ascents[i] - c.style.ascent()
+			for _, g := range c.glyphs {
+				gx, gy := roundGlyph(c.style, currentX+g.x, y+g.y)
+				l.glyphs = append(l.glyphs, glyph{index: g.index, style: c.style, x: gx, y: gy})
+			}
+			if c.style.underline && c.advance != 0 {
+				l.underlines = append(l.underlines, underline{style: c.style, x: currentX, y: y, advance: c.advance})
+			}
+			currentX += c.advance
+			if !spaces || unicode.IsSpace(c.char) {
+				currentX += gap
+			}
+		}
+		currentY += heights[i] + t.LineSpacing
+	}
+	l.glyphs = loadGlyphs(l.glyphs)
+	return
+}
+
+// loadGlyphs updates the atlases with the glyphs, the glyphs they do not have are skipped
+func loadGlyphs(glyphs []glyph) []glyph {
+	fresh := make(map[*Font][]truetype.Index)
+	for _, g := range glyphs {
+		if _, ok := g.style.atlas.Width[g.index]; !ok {
+			fresh[g.style.font] = append(fresh[g.style.font], g.index)
+		} else {
+			// the fresh glyphs do not evict the glyphs of the text
+			g.style.atlas.use(g.index)
+		}
+	}
+	for f, indices := range fresh {
+		f.updateFontAtlas(indices)
+	}
+	loaded := glyphs[:0]
+	for _, g := range glyphs {
+		if _, ok := g.style.atlas.Width[g.index]; ok {
+			loaded = append(loaded, g)
+		}
+	}
+	return loaded
+}
+
+// visualLine the clusters of the line in visual order, without the hyphenation points but the one ending the line,
+// drawn as a hyphen. the spaces at the end of the line stay at the end
+func (t *Text) visualLine(line []cluster, level uint8) []cluster {
+	trimmed := trimSpaces(line)
+	logical := make([]cluster, 0, len(line))
+	for j, c := range trimmed {
+		if c.char == softHyphen {
+			if j != len(trimmed)-1 {
+				continue
+			}
+			c = t.hyphen(c)
+		}
+		logical = append(logical, c)
+	}
+	return append(bidiReorder(logical, level), line[len(trimmed):]...)
+}
+
+// lineWidth the width of the line without the spaces at the end, with the hyphen of a hyphenation point
+func (t *Text) lineWidth(line []cluster) (width float32) {
+	line = trimSpaces(line)
+	for _, c := range line {
+		width += c.advance
+	}
+	if n := len(line); n > 0 && line[n-1].char == softHyphen {
+		width += t.hyphen(line[n-1]).advance
+	}
+	return
+}
+
+// wrap breaks the clusters of the paragraph into lines of maxWidth at most, no wrapping when maxWidth is 0.
+// the lines break after the spaces and the hyphens, at the hyphenation points, and between CJK characters.
+// a word longer than a line is broken between any clusters.
+func (t *Text) wrap(paragraph []styledRune, clusters []cluster, maxWidth float32) (lines [][]cluster) {
+	if maxWidth <= 0 {
+		return [][]cluster{clusters}
+	}
+	var (
+		start     int
+		lastBreak = -1
+		currentX  float32
+	)
+	for i := 0; i < len(clusters); i++ {
+		c := clusters[i]
+		// the line breaks at a hyphenation point when its hyphen fits
+		if i > start && canBreakBefore(paragraph, c.start) && (clusters[i-1].char != softHyphen ||
+			currentX+t.hyphen(clusters[i-1]).advance-t.LetterSpacing <= maxWidth) {
+			lastBreak = i
+		}
+		if i > start && !unicode.IsSpace(c.char) && currentX+c.advance-t.LetterSpacing > maxWidth {
+			end := lastBreak
+			if end <= start {
+				end = i
+			}
+			lines = append(lines, clusters[start:end])
+			start, lastBreak, currentX = end, -1, 0
+			i = start - 1
+			continue
+		}
+		currentX += c.advance
+	}
+	return append(lines, clusters[start:])
+}
+
+// canBreakBefore reports whether a line can start at the character i
+func canBreakBefore(runes []styledRune, i int) bool {
+	prev, char := runes[i-1].char, runes[i].char
+	switch {
+	case unicode.IsSpace(char):
+		// the spaces stay at the end of the line
+		return false
+	case unicode.IsSpace(prev):
+		return true
+	case prev == '-' || prev == softHyphen:
+		// not the minus sign of a number
+		return i > 1 && unicode.IsLetter(runes[i-2].char) && unicode.IsLetter(char)
+	case isCJK(prev) || isCJK(char):
+		return !noLineStart(char) && !noLineEnd(prev)
+	}
+	return false
+}
+func isCJK(char rune) bool {
+	return unicode.In(char, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		char >= 0x3000 && char <= 0x303F || char >= 0xFF00 && char <= 0xFFEF
+}
+
+// noLineStart the closing punctuations, they stay with the character before them
+func noLineStart(char rune) bool {
+	switch char {
+	case '、', '。', '，', '．', '：', '；', '？', '！', '）', '」', '』', '】', '〕', '〉', '》', '’', '”',
+		'ー', '…', '・', 'ゝ', 'ゞ', '々', 'ぁ', 'ぃ', 'ぅ', 'ぇ', 'ぉ', 'っ', 'ゃ', 'ゅ', 'ょ', 'ッ', 'ャ', 'ュ', 'ョ',
+		',', '.', ':', ';', '?', '!', ')', ']', '}':
+		return true
+	}
+	return false
+}
+
+// noLineEnd the opening punctuations, they stay with the character after them
+func noLineEnd(char rune) bool {
+	switch char {
+	case '（', '「', '『', '【', '〔', '〈', '《', '‘', '“', '(', '[', '{':
+		return true
+	}
+	return false
+}
+
+func trimSpaces(line []cluster) []cluster {
+	for len(line) > 0 && unicode.IsSpace(line[len(line)-1].char) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
@@ -0,0 +1,204 @@
+package engoutil
+
+import (
+	"unicode"
+
+	"github.com/EngoEngine/math"
+	"github.com/go-text/typesetting/di"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// The paragraphs are shaped by HarfBuzz, in runs of a style, a bidirectional level and a script,
+// into clusters of glyphs: the joined letters, the ligatures, the marks placed on their base and the reordered signs.
+// The fonts preloaded without their data are not shaped, see PreloadFont, a glyph is drawn per character.
+
+var textShaper shaping.HarfbuzzShaper
+
+// the kern feature is off when the text is not kerned
+var noKerning = []shaping.FontFeature{{Tag: ot.MustNewTag("kern"), Value: 0}}
+
+// cluster the glyphs of characters of the paragraph, the lines break between the clusters
+type cluster struct {
+	// the first character of the cluster in the paragraph, and the number of its characters
+	start, count int
+	// char the first character
+	char  rune
+	style *spanStyle
+	level uint8
+	// the glyphs in visual order
+	glyphs []shapedGlyph
+	// the advance with the letter spacing, in pixels of the font texture
+	advance float32
+}
+
+// shapedGlyph x, y the offset of the glyph from the start of the advance of its cluster, y down
+type shapedGlyph struct {
+	index truetype.Index
+	x, y  float32
+}
+
+// shape the paragraph into clusters in logical order, the levels of the characters are resolved.
+// the hyphenation points and the control characters are clusters without glyphs
+func (t *Text) shape(paragraph []styledRune) (clusters []cluster) {
+	var (
+		text    = make([]rune, len(paragraph))
+		scripts = make([]language.Script, len(paragraph))
+		script  language.Script
+		scale   = t.Font.scale
+	)
+	if scale == 0 {
+		scale = 1
+	}
+	// the common and the inherited characters are in the script of the characters before them, or after them
+	for i, sr := range paragraph {
+		text[i] = sr.char
+		if s := language.LookupScript(sr.char); s != language.Common && s != language.Inherited && s != language.Unknown {
+			script = s
+		}
+		scripts[i] = script
+	}
+	script = language.Common
+	for _, s := range scripts {
+		if s != 0 {
+			script = s
+			break
+		}
+	}
+	for i := 0; i < len(scripts) && scripts[i] == 0; i++ {
+		scripts[i] = script
+	}
+
+	for start := 0; start < len(paragraph); {
+		first := paragraph[start]
+		end := start + 1
+		if !unshaped(first.char) {
+			for end < len(paragraph) && !unshaped(text[end]) && paragraph[end].style == first.style &&
+				paragraph[end].level == first.level && scripts[end] == scripts[start] {
+				end++
+			}
+		}
+		var run []cluster
+		switch face := shapingFaces[first.style.font.URL]; {
+		case unshaped(first.char):
+			run = []cluster{{start: start, count: 1, char: first.char, style: first.style, level: first.level}}
+		case face == nil:
+			run = t.shapeRunes(paragraph[start:end], start)
+		default:
+			input := shaping.Input{
+				Text:      text,
+				RunStart:  start,
+				RunEnd:    end,
+				Direction: di.DirectionLTR,
+				Face:      face,
+				Size:      fixed.Int26_6(float32(first.style.font.Size)*scale*64 + 0.5),
+				Script:    scripts[start],
+			}
+			if first.level%2 == 1 {
+				input.Direction = di.DirectionRTL
+			}
+			if !t.Kerning {
+				input.FontFeatures = noKerning
+			}
+			run = clustersOf(textShaper.Shape(input), paragraph, start, end)
+		}
+		for i := range run {
+			if c := &run[i]; !unshaped(c.char) {
+				c.advance += t.LetterSpacing
+				if c.style.bold {
+					c.advance += c.style.boldOffset()
+				}
+			}
+		}
+		clusters = append(clusters, run...)
+		start = end
+	}
+	return
+}
+
+// unshaped the hyphenation points, drawn as hyphens at the end of the lines only, and the control characters
+func unshaped(char rune) bool {
+	return char == softHyphen || unicode.IsControl(char)
+}
+
+// clustersOf the clusters of the shaped run in logical order, the glyphs of a right-to-left run are reversed
+func clustersOf(out shaping.Output, paragraph []styledRune, start, end int) (clusters []cluster) {
+	first := paragraph[start]
+	for i := 0; i < len(out.Glyphs); {
+		index := out.Glyphs[i].ClusterIndex
+		c := cluster{start: index, char: paragraph[index].char, style: first.style, level: first.level}
+		for ; i < len(out.Glyphs) && out.Glyphs[i].ClusterIndex == index; i++ {
+			g := out.Glyphs[i]
+			c.glyphs = append(c.glyphs, shapedGlyph{
+				index: truetype.Index(g.GlyphID),
+				x:     c.advance + fixedFloat(g.XOffset),
+				y:     -fixedFloat(g.YOffset),
+			})
+			c.advance += fixedFloat(g.XAdvance)
+		}
+		clusters = append(clusters, c)
+	}
+	if first.level%2 == 1 {
+		for a, b := 0, len(clusters)-1; a < b; a, b = a+1, b-1 {
+			clusters[a], clusters[b] = clusters[b], clusters[a]
+		}
+	}
+	for i := range clusters {
+		next := end
+		if i+1 < len(clusters) {
+			next = clusters[i+1].start
+		}
+		clusters[i].count = next - clusters[i].start
+	}
+	return
+}
+
+// shapeRunes the clusters of a run of a font not shaped, a glyph per character.
+// the brackets of the right-to-left runs are mirrored, the pairs are kerned by the kern table when the text is kerned
+func (t *Text) shapeRunes(run []styledRune, start int) (clusters []cluster) {
+	rtl := run[0].level%2 == 1
+	for i, sr := range run {
+		f, char := sr.style.font, sr.char
+		if rtl {
+			char = []rune(bidi.ReverseString(string(char)))[0]
+		}
+		c := cluster{start: start + i, count: 1, char: sr.char, style: sr.style, level: sr.level}
+		c.glyphs = []shapedGlyph{{index: f.TTF.Index(char)}}
+		if advance, ok := f.face.GlyphAdvance(char); ok {
+			c.advance = fixedFloat(advance)
+		}
+		if i > 0 && t.Kerning {
+			// the kerning of the pair is added to the advance of the glyph on the left
+			if rtl {
+				c.advance += f.kern(sr.char, run[i-1].char)
+			} else {
+				clusters[i-1].advance += f.kern(run[i-1].char, sr.char)
+			}
+		}
+		clusters = append(clusters, c)
+	}
+	return
+}
+
+// hyphen the cluster of the hyphen drawn at the hyphenation point c
+func (t *Text) hyphen(c cluster) cluster {
+	hyphen := t.shape([]styledRune{{char: '-', style: c.style, level: c.level}})[0]
+	hyphen.start, hyphen.count = c.start, c.count
+	return hyphen
+}
+
+func fixedFloat(v fixed.Int26_6) float32 {
+	return float32(v) / 64
+}
+
+// roundGlyph the glyphs of the bitmap atlases are drawn on whole pixels
+func roundGlyph(style *spanStyle, x, y float32) (float32, float32) {
+	if style.font.SDF {
+		return x, y
+	}
+	return math.Floor(x + 0.5), math.Floor(y + 0.5)
+}
@@ -0,0 +1,359 @@
+package engoutil
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/EngoEngine/math"
+)
+
+// Span is a run of rich text, the zero values are the style of the text
+type Span struct {
+	Text string
+	// nil is the color of the text
+	Color *Color
+	// Size and URL change the font, the fonts must be preloaded
+	Size float32
+	URL  string
+	// Bold and Italic are synthesized from the font
+	Bold, Italic, Underline bool
+}
+
+// spanStyle the font and the decorations of the characters of a span
+type spanStyle struct {
+	font  *Font
+	atlas *FontAtlas
+	// the metrics of the atlas are multiplied by it, see Font.ratio
+	ratio float32
+	// nil is the color of the text
+	color                   *Color
+	bold, italic, underline bool
+}
+
+// the slant of italic characters
+const italicSkew = 0.2
+
+// boldOffset the bold characters are drawn twice, this far apart
+func (s *spanStyle) boldOffset() float32 {
+	return math.Max(1, math.Floor(float32(s.font.Size)*s.font.scale/24+0.5))
+}
+
+// underlineRect the top and the thickness of the underline, below the baseline
+func (s *spanStyle) underlineRect() (offset, thickness float32) {
+	thickness = math.Max(1, math.Floor(float32(s.font.Size)*s.font.scale/16+0.5))
+	return math.Floor(s.descent()/3 + 0.5), thickness
+}
+
+// ascent and descent of the font, in pixels of the font texture of the text
+func (s *spanStyle) ascent() float32  { return s.atlas.Ascent * s.ratio }
+func (s *spanStyle) descent() float32 { return (s.atlas.LineHeight - s.atlas.Ascent) * s.ratio }
+
+// the fonts of the characters missing from the fonts of the texts, after Text.Fallbacks
+var fallbackFonts []string
+
+// SetFallbackFonts the fonts of the characters missing from the fonts of the texts, in order, after the fallbacks
+// of the texts. the fonts must be preloaded, the texts are laid out with them when they change
+func SetFallbackFonts(urls ...string) {
+	fallbackFonts = append([]string(nil), urls...)
+}
+
+func (t *Text) baseStyle() *spanStyle {
+	return &spanStyle{font: t.Font, atlas: t.Font.updateFontAtlas(nil), ratio: t.Font.ratio()}
+}
+
+// styledRunes the characters of the text, or of the spans,
+// the characters missing from their fonts are drawn with the fallback fonts
+func (t *Text) styledRunes(base *spanStyle) (runes []styledRune) {
+	if t.Spans == nil {
+		for _, char := range t.Text {
+			runes = append(runes, styledRune{char: char, style: base})
+		}
+	}
+	for _, span := range t.Spans {
+		f := t.spanFont(span)
+		style := &spanStyle{
+			font:      f,
+			atlas:     f.updateFontAtlas(nil),
+			ratio:     f.ratio(),
+			color:     span.Color,
+			bold:      span.Bold,
+			italic:    span.Italic,
+			underline: span.Underline,
+		}
+		for _, char := range span.Text {
+			runes = append(runes, styledRune{char: char, style: style})
+		}
+	}
+	fallbacks := make(map[fallbackKey]*spanStyle)
+	for i, sr := range runes {
+		runes[i].style = t.fallback(sr.char, sr.style, fallbacks)
+	}
+	return
+}
+
+type spanFontKey struct {
+	url   string
+	size  float64
+	scale float32
+	sdf   bool
+}
+
+// the fonts of the spans are shared by the texts, with their atlases
+var spanFonts = make(map[spanFontKey]*Font)
+
+func (t *Text) spanFont(span Span) *Font {
+	url, size := span.URL, float64(span.Size)
+	if url == "" {
+		url = t.Font.URL
+	}
+	if size == 0 {
+		size = t.Font.Size
+	}
+	return t.fontOf(url, size)
+}
+
+func (t *Text) fontOf(url string, size float64) *Font {
+	if url == t.Font.URL && size == t.Font.Size {
+		return t.Font
+	}
+	key := spanFontKey{url, size, t.Font.scale, t.Font.SDF}
+	if f, ok := spanFonts[key]; ok {
+		return f
+	}
+	f := &Font{URL: url, Size: size, SDF: t.Font.SDF, scale: t.Font.scale}
+	if err := f.CreatePreloaded(); err != nil {
+		warning("%q CreatePreloaded. Error was: %s", url, err.Error())
+		return t.Font
+	}
+	spanFonts[key] = f
+	return f
+}
+
+type fallbackKey struct {
+	style *spanStyle
+	font  *Font
+}
+
+// fallback the style of the character in the first fallback font having it, when the font of the style does not.
+// the style without the fallback fonts having it
+func (t *Text) fallback(char rune, style *spanStyle, cache map[fallbackKey]*spanStyle) *spanStyle {
+	if char < ' ' || style.font.TTF == nil || style.font.TTF.Index(char) != 0 {
+		return style
+	}
+	for _, urls := range [][]string{t.Fallbacks, fallbackFonts} {
+		for _, url := range urls {
+			f := t.fontOf(url, style.font.Size)
+			if f == style.font || f.TTF == nil || f.TTF.Index(char) == 0 {
+				continue
+			}
+			key := fallbackKey{style, f}
+			if cache[key] == nil {
+				fallback := *style
+				// the glyphs are added to the atlas with the glyphs of the layout
+				fallback.font, fallback.atlas, fallback.ratio = f, f.updateFontAtlas(nil), f.ratio()
+				cache[key] = &fallback
+			}
+			return cache[key]
+		}
+	}
+	return style
+}
+
+func spansEqual(a, b []Span) bool {
+	if len(a) != len(b) || (a == nil) != (b == nil) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		if x.Text != y.Text || x.Size != y.Size || x.URL != y.URL || x.Bold != y.Bold || x.Italic != y.Italic || x.Underline != y.Underline {
+			return false
+		}
+		if (x.Color == nil) != (y.Color == nil) || x.Color != nil && x.Color.raw != y.Color.raw {
+			return false
+		}
+	}
+	return true
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseMarkup parses the BBCode of rich text into spans, the tags nest:
+//
+//	[color=#f00]..[/color] [size=24]..[/size] [font=url]..[/font] [b]..[/b] [i]..[/i] [u]..[/u]
+//
+// the colors are #rgb, #rgba, #rrggbb or #rrggbbaa. "[[" is a "[", the unknown tags are text.
+// a tag closed before the tags opened inside it closes them too, they are opened again after it.
+func ParseMarkup(markup string) (spans []Span) {
+	type tag struct {
+		name, value string
+		// the span before the tag
+		span Span
+	}
+	var (
+		current Span
+		stack   []tag
+		text    strings.Builder
+	)
+	flush := func() {
+		if text.Len() > 0 {
+			span := current
+			span.Text = text.String()
+			spans = append(spans, span)
+			text.Reset()
+		}
+	}
+	for len(markup) > 0 {
+		open := strings.IndexByte(markup, '[')
+		if open < 0 {
+			text.WriteString(markup)
+			break
+		}
+		text.WriteString(markup[:open])
+		markup = markup[open:]
+		if strings.HasPrefix(markup, "[[") {
+			text.WriteByte('[')
+			markup = markup[2:]
+			continue
+		}
+		end := strings.IndexByte(markup, ']')
+		if end < 0 {
+			text.WriteString(markup)
+			break
+		}
+		content := markup[1:end]
+
+		if strings.HasPrefix(content, "/") {
+			name := content[1:]
+			closed := false
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name == name {
+					flush()
+					inner := append([]tag(nil), stack[i+1:]...)
+					current = stack[i].span
+					stack = stack[:i]
+					for _, t := range inner {
+						stack = append(stack, tag{t.name, t.value, current})
+						applyTag(&current, t.name, t.value)
+					}
+					closed = true
+					break
+				}
+			}
+			if !closed {
+				text.WriteString(markup[:end+1])
+			}
+			markup = markup[end+1:]
+			continue
+		}
+
+		name, value := content, ""
+		if i := strings.IndexByte(content, '='); i >= 0 {
+			name, value = content[:i], strings.Trim(content[i+1:], `"'`)
+		}
+		next := current
+		if !applyTag(&next, name, value) {
+			text.WriteString(markup[:end+1])
+		} else {
+			flush()
+			stack = append(stack, tag{name, value, current})
+			current = next
+		}
+		markup = markup[end+1:]
+	}
+	flush()
+	return
+}
+
+// applyTag sets the attribute of the tag on the span, false for the unknown tags and the invalid values
+func applyTag(span *Span, name, value string) bool {
+	switch name {
+	case "color":
+		clr, ok := parseHexColor(value)
+		if ok {
+			span.Color = NewColor(clr)
+		}
+		return ok
+	case "size":
+		size, err := strconv.ParseFloat(value, 32)
+		if err != nil || size <= 0 {
+			return false
+		}
+		span.Size = float32(size)
+	case "font":
+		if value == "" {
+			return false
+		}
+		span.URL = value
+	case "b":
+		span.Bold = true
+	case "i":
+		span.Italic = true
+	case "u":
+		span.Underline = true
+	default:
+		return false
+	}
+	return true
+}
+
+// parseHexColor #rgb, #rgba, #rrggbb or #rrggbbaa, opaque without the alpha
+func parseHexColor(s string) (uint32, bool) {
+	s = strings.TrimPrefix(s, "#")
+	switch len(s) {
+	case 3, 4:
+		long := make([]byte, 0, 8)
+		for i := 0; i < len(s); i++ {
+			long = append(long, s[i], s[i])
+		}
+		s = string(long)
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	if len(s) != 8 {
+		return 0, false
+	}
+	clr, err := strconv.ParseUint(s, 16, 32)
+	return uint32(clr), err == nil
+}
+
+// NewRichText a text of the markup, see ParseMarkup
+func NewRichText(markup string, x, y, ax, ay, size float32, color uint32, style *TextStyle) *Shape {
+	s := newText("", x, y, ax, ay, size, color, style)
+	s.SetMarkup(markup)
+	return s
+}
+
+// (*Shape) SetSpans 设置富文本, the text is the text of the spans
+func (s *Shape) SetSpans(spans ...Span) {
+	if !s.requireKind(SHAPE_KIND_TEXT, "SetSpans") {
+		return
+	}
+	if s.Render == nil || s.Render.Drawable == nil {
+		return
+	}
+	if t, ok := s.Render.Drawable.(*Text); ok {
+		t.Spans = append(make([]Span, 0, len(spans)), spans...)
+		var text strings.Builder
+		for _, span := range spans {
+			text.WriteString(span.Text)
+		}
+		t.Text = text.String()
+		s.SetAnchor(s.attr[2], s.attr[3])
+	}
+}
+
+// (*Shape) SetMarkup 设置富文本, see ParseMarkup
+func (s *Shape) SetMarkup(markup string) {
+	s.SetSpans(ParseMarkup(markup)...)
+}
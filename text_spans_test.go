@@ -0,0 +1,42 @@
+package engoutil
+
+import "testing"
+
+func TestParseMarkup(t *testing.T) {
+	type style struct {
+		text                    string
+		size                    float32
+		url                     string
+		color                   uint32
+		bold, italic, underline bool
+	}
+	for _, c := range []struct {
+		markup string
+		want   []style
+	}{
+		{"plain", []style{{text: "plain"}}},
+		{"a[b]b[/b]c", []style{{text: "a"}, {text: "b", bold: true}, {text: "c"}}},
+		{"[color=#f00]r[size=24]big[/size][/color]", []style{{text: "r", color: 0xff0000ff}, {text: "big", color: 0xff0000ff, size: 24}}},
+		{"[font=\"x.ttf\"][u]f[/u][/font]", []style{{text: "f", url: "x.ttf", underline: true}}},
+		// the italic opened inside the bold is opened again after it
+		{"[b]a[i]b[/b]c[/i]d", []style{{text: "a", bold: true}, {text: "b", bold: true, italic: true}, {text: "c", italic: true}, {text: "d"}}},
+		{"[[b]] [x]y[/x] [/i]", []style{{text: "[b]] [x]y[/x] [/i]"}}},
+		{"[size=0]s[color=red]c", []style{{text: "[size=0]s[color=red]c"}}},
+		{"open[b", []style{{text: "open[b"}}},
+	} {
+		spans := ParseMarkup(c.markup)
+		if len(spans) != len(c.want) {
+			t.Errorf("%q: %d spans %+v, want %d", c.markup, len(spans), spans, len(c.want))
+			continue
+		}
+		for i, span := range spans {
+			got := style{text: span.Text, size: span.Size, url: span.URL, bold: span.Bold, italic: span.Italic, underline: span.Underline}
+			if span.Color != nil {
+				got.color = span.Color.raw
+			}
+			if got != c.want[i] {
+				t.Errorf("%q: span %d is %+v, want %+v", c.markup, i, got, c.want[i])
+			}
+		}
+	}
+}